@@ -23,11 +23,11 @@ func main() {
 }
 
 func RunApp() {
-	myApp := app.New()
+	myApp := app.NewWithID("io.github.ruimbarroso.calculator")
 	myApp.Settings().SetTheme(&mythemes.AppTheme{})
 	w := myApp.NewWindow("Calculator")
 
-	w.SetContent(views.CreateApp())
+	w.SetContent(views.CreateApp(w))
 
 	iconResource, err := LoadIconAsset()
 	if err != nil {
@@ -37,7 +37,7 @@ func RunApp() {
 		w.SetIcon(iconResource)
 	}
 
-	w.Resize(fyne.NewSize(350, 550))
+	w.Resize(fyne.NewSize(350, 615))
 	w.SetFixedSize(true)
 
 	w.ShowAndRun()
@@ -4,22 +4,33 @@
 package controller
 
 import (
+	"calculator/src/evaluator"
 	"calculator/src/model"
 	"calculator/src/parser"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"fyne.io/fyne/v2/widget"
-	"github.com/sanity-io/litter"
 )
 
 const (
-	Cursor   = "|"
+	// Cursor marks the insertion point in the equation. It is a box drawing
+	// line rather than "|", which is the bitwise or operator.
+	Cursor   = "│"
 	ErrorMSG = "F!!!"
 )
 
 type CalculatorController struct {
 	equation     model.Equation
+	env          *parser.Env
+	options      evaluator.Options
+	fraction     evaluator.FractionFormat
+	complex      evaluator.ComplexFormat
+	formatter    evaluator.Formatter
+	base         int
+	result       evaluator.Result
+	resultBase   int
 	Display      *widget.Entry
 	History      []model.Equation
 	historyIndex int
@@ -41,33 +52,159 @@ func (t *CalculatorController) OldCalculate() {
 }
 
 func (t *CalculatorController) Calculate() {
-	expr := parser.Parse(strings.ReplaceAll(t.equation.Equation, "|", ""))
+	// Equations are read in the locale results are written in.
+	opts := parser.Options{Locale: t.formatter.Locale, Env: t.env}
+	expr, err := parser.ParseWithOptions(strings.ReplaceAll(t.equation.Equation, Cursor, ""), opts)
 
-	if expr == nil {
-		t.Display.SetText(ErrorMSG)
+	if err != nil {
+		t.ShowError(err)
 		return
 	}
 
-	res := expr.Eval()
-	fmt.Printf("Equation: %s\nResult: %g\n", expr.ToString(), res)
-	litter.Dump(expr)
+	res, err := evaluator.Run(expr, t.env, t.options)
+	if err != nil {
+		t.ShowError(err)
+		return
+	}
 
+	// An equation recalled from the history is shown in the base it was
+	// shown in before.
+	base := t.equation.Base
+	if base == 0 {
+		base = t.base
+	}
+	t.equation.Base = base
 	t.InsertInHistory()
 	t.Clear()
 
-	t.Display.SetText(fmt.Sprintf("%g", res))
+	t.result = res
+	t.resultBase = base
+	t.Display.SetText(t.FormatResult(res))
+}
 
+// ShowError keeps the current equation on the display and writes the error
+// message below it so the user can see what went wrong.
+func (t *CalculatorController) ShowError(err error) {
+	t.Display.SetText(fmt.Sprintf("%s\n%s %v", t.equation.Equation, ErrorMSG, err))
 }
 func New(display *widget.Entry) *CalculatorController {
 	return &CalculatorController{
 		Display:      display,
 		equation:     model.Equation{Equation: Cursor},
+		env:          parser.NewEnv(),
+		options:      evaluator.Options{Backend: evaluator.Float, Precision: evaluator.DefaultPrecision, Scale: evaluator.DefaultScale},
+		base:         10,
 		cursorIndex:  0,
 		History:      make([]model.Equation, 0),
 		historyIndex: -1,
 	}
 }
+
+// AngleMode returns the angle unit trigonometric functions currently use.
+func (t *CalculatorController) AngleMode() parser.AngleMode {
+	return t.env.Angle
+}
+
+// SetAngleMode changes the angle unit used by the next calculations.
+func (t *CalculatorController) SetAngleMode(mode parser.AngleMode) {
+	t.env.Angle = mode
+}
+
+// Options returns the backend configuration used by Calculate.
+func (t *CalculatorController) Options() evaluator.Options {
+	return t.options
+}
+
+// SetOptions changes the backend the next calculations are evaluated with.
+func (t *CalculatorController) SetOptions(opts evaluator.Options) {
+	t.options = opts
+}
+
+// FractionFormat returns the format exact rational results are displayed in.
+func (t *CalculatorController) FractionFormat() evaluator.FractionFormat {
+	return t.fraction
+}
+
+// SetFractionFormat changes the format exact rational results are displayed in,
+// redisplaying the result currently shown if there is one.
+func (t *CalculatorController) SetFractionFormat(format evaluator.FractionFormat) {
+	t.fraction = format
+	t.redisplayResult()
+}
+
+// ComplexFormat returns the format complex results are displayed in.
+func (t *CalculatorController) ComplexFormat() evaluator.ComplexFormat {
+	return t.complex
+}
+
+// SetComplexFormat changes the format complex results are displayed in,
+// redisplaying the result currently shown if there is one.
+func (t *CalculatorController) SetComplexFormat(format evaluator.ComplexFormat) {
+	t.complex = format
+	t.redisplayResult()
+}
+
+// Formatter returns the format numbers of results are displayed in. Its
+// locale is also the one equations are read in.
+func (t *CalculatorController) Formatter() evaluator.Formatter {
+	return t.formatter
+}
+
+// SetFormatter changes the format numbers of results are displayed in,
+// redisplaying the result currently shown if there is one.
+func (t *CalculatorController) SetFormatter(formatter evaluator.Formatter) {
+	t.formatter = formatter
+	t.redisplayResult()
+}
+
+// Base returns the base new results are displayed in.
+func (t *CalculatorController) Base() int {
+	return t.base
+}
+
+// SetBase changes the base results are displayed in, redisplaying the result
+// currently shown if there is one. Its history entry then remembers the new
+// base, as does an equation recalled from the history and not computed yet.
+func (t *CalculatorController) SetBase(base int) {
+	t.base = base
+	if t.result != nil {
+		t.resultBase = base
+		t.History[t.historyIndex].Base = base
+	} else {
+		t.equation.Base = base
+	}
+	t.redisplayResult()
+}
+
+// redisplayResult formats the result on the display again after a display
+// setting changed. It does nothing once the user started a new equation.
+func (t *CalculatorController) redisplayResult() {
+	if t.result != nil {
+		t.Display.SetText(t.FormatResult(t.result))
+	}
+}
+
+// FormatResult returns the text res is displayed with. Real results are
+// written in the base they were computed for unless they were converted to
+// one, as in "255 to hex".
+func (t *CalculatorController) FormatResult(res evaluator.Result) string {
+	if _, converted := res.(evaluator.RadixValue); !converted && t.resultBase != 10 {
+		if text, ok := evaluator.FormatRadix(res, t.resultBase); ok {
+			return text
+		}
+	}
+
+	switch value := res.(type) {
+	case evaluator.RatValue:
+		return t.formatter.Localize(value.Format(t.fraction))
+	case evaluator.ComplexValue:
+		return t.formatter.Localize(value.Format(t.complex))
+	default:
+		return t.formatter.FormatResult(res)
+	}
+}
 func (t *CalculatorController) WriteInDisplay() {
+	t.result = nil
 	t.Display.SetText(t.equation.Equation)
 }
 func (t *CalculatorController) Insert(c string) {
@@ -79,16 +216,14 @@ func (t *CalculatorController) Insert(c string) {
 func (t *CalculatorController) Delete() {
 	res := strings.Split(t.equation.Equation, Cursor)
 
-	if res[0] == "" {
-		t.equation.Equation = res[0] + Cursor + res[1]
-	} else {
-		t.equation.Equation = res[0][:len(res[0])-1] + Cursor + res[1]
-	}
+	// Symbols such as "×" or "²" take more than one byte.
+	_, size := utf8.DecodeLastRuneInString(res[0])
+	t.equation.Equation = res[0][:len(res[0])-size] + Cursor + res[1]
 
 	t.WriteInDisplay()
 }
 func (t *CalculatorController) Clear() {
-	t.equation.Equation = Cursor
+	t.equation = model.Equation{Equation: Cursor}
 	t.WriteInDisplay()
 }
 
@@ -96,7 +231,8 @@ func (t *CalculatorController) MoveCursorLeft() {
 	res := strings.Split(t.equation.Equation, Cursor)
 
 	if res[0] != "" {
-		t.equation.Equation = res[0][:len(res[0])-1] + Cursor + res[0][len(res[0])-1:] + res[1]
+		_, size := utf8.DecodeLastRuneInString(res[0])
+		t.equation.Equation = res[0][:len(res[0])-size] + Cursor + res[0][len(res[0])-size:] + res[1]
 	}
 
 	t.WriteInDisplay()
@@ -105,7 +241,8 @@ func (t *CalculatorController) MoveCursorRigth() {
 	res := strings.Split(t.equation.Equation, Cursor)
 
 	if res[1] != "" {
-		t.equation.Equation = res[0] + res[1][:1] + Cursor + res[1][1:]
+		_, size := utf8.DecodeRuneInString(res[1])
+		t.equation.Equation = res[0] + res[1][:size] + Cursor + res[1][size:]
 	}
 	t.WriteInDisplay()
 }
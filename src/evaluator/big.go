@@ -0,0 +1,511 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package evaluator
+
+import (
+	"calculator/src/lexer"
+	"calculator/src/parser"
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxExactBits bounds the size of integer powers and factorials computed
+// exactly. Larger results are approximated with float64 instead.
+const maxExactBits = 1 << 20
+
+// BigValue is a value of the Big backend. Integer results of integer operands
+// are kept exact in Int; every other value is held in Float.
+type BigValue struct {
+	// Int is set when the value is an exact integer.
+	Int *big.Int
+	// Float is set when the value is not known to be an exact integer.
+	Float *big.Float
+	// digits is the number of significant digits String shows for Float.
+	digits uint
+}
+
+func (v BigValue) String() string {
+	if v.Int != nil {
+		return v.Int.String()
+	}
+	return v.Float.Text('g', int(v.digits))
+}
+func (v BigValue) Float64() float64 {
+	if v.Int != nil {
+		value, _ := new(big.Float).SetInt(v.Int).Float64()
+		return value
+	}
+	value, _ := v.Float.Float64()
+	return value
+}
+
+// bigConstants holds the mathematical constants to more digits than any
+// precision the Big backend is likely to be configured with.
+var bigConstants = map[string]string{
+	"pi":  "3.14159265358979323846264338327950288419716939937510582097494459230781640628620899862803482534211706798",
+	"tau": "6.28318530717958647692528676655900576839433879875021164194988918461563281257241799725606965068423413596",
+	"e":   "2.71828182845904523536028747135266249775724709369995957496696762772407663035354759457138217852516642742",
+	"phi": "1.61803398874989484820458683436563811772030917980576286213544862270526046281890244970720720418939113748",
+}
+
+// errNotReal is returned when a result is not a real number, such as 0/0.
+var errNotReal = errors.New("result is not a real number")
+
+// bigArithmetic implements Arithmetic over BigValue.
+// Operations with no exact math/big counterpart, such as the trigonometric
+// functions, are computed in float64 and converted back.
+type bigArithmetic struct {
+	digits uint
+	prec   uint
+	env    *parser.Env
+}
+
+// NewBigArithmetic creates the Big backend arithmetic computing with the given
+// number of significant decimal digits, reading the angle mode from env.
+func NewBigArithmetic(digits uint, env *parser.Env) Arithmetic[BigValue] {
+	if digits == 0 {
+		digits = DefaultPrecision
+	}
+	// A few guard digits keep the displayed ones correct after rounding errors.
+	prec := uint(math.Ceil(float64(digits+5) * math.Log2(10)))
+	return bigArithmetic{digits: digits, prec: prec, env: env}
+}
+
+func (a bigArithmetic) fromInt(i *big.Int) BigValue {
+	return BigValue{Int: i, digits: a.digits}
+}
+func (a bigArithmetic) fromBigFloat(f *big.Float) BigValue {
+	return BigValue{Float: f, digits: a.digits}
+}
+func (a bigArithmetic) newFloat() *big.Float {
+	return new(big.Float).SetPrec(a.prec)
+}
+func (a bigArithmetic) float(v BigValue) *big.Float {
+	if v.Int != nil {
+		return a.newFloat().SetInt(v.Int)
+	}
+	return v.Float
+}
+
+func (a bigArithmetic) Literal(n parser.NumberExpr) (BigValue, error) {
+	if n.Literal == "" {
+		return a.FromFloat(n.Value)
+	}
+
+	digits, base := parser.SplitNumber(n.Literal)
+	if base != 10 || !strings.ContainsAny(digits, ".eE") {
+		i, ok := new(big.Int).SetString(digits, base)
+		if !ok {
+			return BigValue{}, errors.New("invalid number " + n.Literal)
+		}
+		return a.fromInt(i), nil
+	}
+
+	f, _, err := big.ParseFloat(digits, 10, a.prec, big.ToNearestEven)
+	if err != nil {
+		return BigValue{}, err
+	}
+	return a.fromBigFloat(f), nil
+}
+
+// FromFloat converts x through its shortest decimal representation, so that
+// 0.1 becomes 0.1 rather than the binary fraction float64 actually stores.
+func (a bigArithmetic) FromFloat(x float64) (BigValue, error) {
+	if math.IsNaN(x) {
+		return BigValue{}, errNotReal
+	}
+	if x == math.Trunc(x) && math.Abs(x) <= 1<<53 {
+		return a.fromInt(big.NewInt(int64(x))), nil
+	}
+	if math.IsInf(x, 0) {
+		return a.fromBigFloat(a.newFloat().SetInf(x < 0)), nil
+	}
+
+	f, _, err := big.ParseFloat(strconv.FormatFloat(x, 'g', -1, 64), 10, a.prec, big.ToNearestEven)
+	if err != nil {
+		return BigValue{}, err
+	}
+	return a.fromBigFloat(f), nil
+}
+
+func (a bigArithmetic) Constant(name string) (BigValue, bool) {
+	digits, known := bigConstants[name]
+	if !known {
+		return BigValue{}, false
+	}
+	f, _, err := big.ParseFloat(digits, 10, a.prec, big.ToNearestEven)
+	return a.fromBigFloat(f), err == nil
+}
+
+func (a bigArithmetic) Binary(op lexer.TokenKind, x, y BigValue) (BigValue, error) {
+	// Like the other exact backends, dividing by zero is an error rather
+	// than an infinity.
+	switch {
+	case (op == lexer.SLASH || op == lexer.PERCENT) && a.float(y).Sign() == 0,
+		op == lexer.HAT && a.float(x).Sign() == 0 && a.float(y).Sign() < 0:
+		return BigValue{}, errDivisionByZero
+	}
+	if x.Int != nil && y.Int != nil {
+		if result, exact := intBinary(op, x.Int, y.Int); exact {
+			return a.fromInt(result), nil
+		}
+	}
+
+	return a.guard(func() (BigValue, error) {
+		fx, fy := a.float(x), a.float(y)
+		switch op {
+		case lexer.PLUS:
+			return a.fromBigFloat(a.newFloat().Add(fx, fy)), nil
+		case lexer.DASH:
+			return a.fromBigFloat(a.newFloat().Sub(fx, fy)), nil
+		case lexer.STAR:
+			return a.fromBigFloat(a.newFloat().Mul(fx, fy)), nil
+		case lexer.SLASH:
+			return a.fromBigFloat(a.newFloat().Quo(fx, fy)), nil
+		case lexer.HAT:
+			if fy.IsInt() && !fy.IsInf() {
+				if n, accuracy := fy.Int64(); accuracy == big.Exact && n > -maxExactBits && n < maxExactBits {
+					return a.fromBigFloat(a.pow(fx, n)), nil
+				}
+			}
+			if power, ok := a.realPow(fx, fy); ok {
+				return a.fromBigFloat(power), nil
+			}
+		case lexer.ROOT:
+			if fy.Cmp(big.NewFloat(2)) == 0 && fx.Sign() >= 0 {
+				return a.fromBigFloat(a.newFloat().Sqrt(fx)), nil
+			}
+			if fy.Sign() != 0 && !fy.IsInf() {
+				if root, ok := a.realPow(fx, a.newFloat().Quo(big.NewFloat(1), fy)); ok {
+					return a.fromBigFloat(root), nil
+				}
+			}
+		}
+
+		return a.approximate(func(args []float64) (float64, error) {
+			return parser.BinaryOp(op, args[0], args[1])
+		}, x, y)
+	})
+}
+
+func (a bigArithmetic) Unary(op lexer.TokenKind, x BigValue) (BigValue, error) {
+	if op == lexer.TILDE && x.Int != nil {
+		return a.fromInt(new(big.Int).Not(x.Int)), nil
+	}
+	if op != lexer.DASH {
+		return a.approximate(func(args []float64) (float64, error) {
+			return parser.UnaryOp(op, args[0])
+		}, x)
+	}
+
+	if x.Int != nil {
+		return a.fromInt(new(big.Int).Neg(x.Int)), nil
+	}
+	return a.fromBigFloat(a.newFloat().Neg(x.Float)), nil
+}
+
+func (a bigArithmetic) Postfix(op lexer.TokenKind, x BigValue) (BigValue, error) {
+	switch op {
+	case lexer.BANG:
+		if x.Int != nil && x.Int.Sign() >= 0 && x.Int.IsInt64() && x.Int.Int64() <= maxExactBits/32 {
+			return a.fromInt(new(big.Int).MulRange(1, x.Int.Int64())), nil
+		}
+	case lexer.PERCENT:
+		return a.Binary(lexer.SLASH, x, a.fromInt(big.NewInt(100)))
+	}
+
+	return a.approximate(func(args []float64) (float64, error) {
+		return parser.PostfixOp(a.env, op, args[0])
+	}, x)
+}
+
+func (a bigArithmetic) Call(name string, args []BigValue) (BigValue, error) {
+	switch name {
+	case "abs":
+		if args[0].Int != nil {
+			return a.fromInt(new(big.Int).Abs(args[0].Int)), nil
+		}
+		return a.fromBigFloat(a.newFloat().Abs(args[0].Float)), nil
+	case "sqrt":
+		if args[0].Int != nil && args[0].Int.Sign() >= 0 {
+			root := new(big.Int).Sqrt(args[0].Int)
+			if new(big.Int).Mul(root, root).Cmp(args[0].Int) == 0 {
+				return a.fromInt(root), nil
+			}
+		}
+		if f := a.float(args[0]); f.Sign() >= 0 {
+			return a.fromBigFloat(a.newFloat().Sqrt(f)), nil
+		}
+	case "floor", "ceil", "round":
+		if len(args) == 1 {
+			return a.roundTo(name, args[0]), nil
+		}
+		// Like the float64 builtin, the number of decimal places is truncated.
+		places := args[1].Int
+		if places == nil {
+			places, _ = args[1].Float.Int(nil)
+		}
+		if places != nil && places.IsInt64() && places.CmpAbs(big.NewInt(maxExactBits)) < 0 {
+			return a.roundDigits(args[0], places.Int64()), nil
+		}
+	case "min", "max":
+		result := args[0]
+		for _, arg := range args[1:] {
+			if c := a.cmp(arg, result); (name == "min" && c < 0) || (name == "max" && c > 0) {
+				result = arg
+			}
+		}
+		return result, nil
+	}
+
+	return a.approximate(func(floats []float64) (float64, error) {
+		return parser.CallFunction(a.env, name, floats)
+	}, args...)
+}
+
+// approximate computes fn over the float64 approximations of args.
+func (a bigArithmetic) approximate(fn func(args []float64) (float64, error), args ...BigValue) (BigValue, error) {
+	floats := make([]float64, len(args))
+	for i, arg := range args {
+		floats[i] = arg.Float64()
+	}
+
+	result, err := fn(floats)
+	if err != nil {
+		return BigValue{}, err
+	}
+	return a.FromFloat(result)
+}
+
+// guard turns the big.ErrNaN panics math/big raises for operations such as
+// Inf - Inf into an error.
+func (a bigArithmetic) guard(fn func() (BigValue, error)) (result BigValue, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isNaN := r.(big.ErrNaN); !isNaN {
+				panic(r)
+			}
+			err = errNotReal
+		}
+	}()
+	return fn()
+}
+
+// pow raises x to the integer power n by repeated squaring.
+func (a bigArithmetic) pow(x *big.Float, n int64) *big.Float {
+	result := a.newFloat().SetInt64(1)
+	base := a.newFloat().Set(x)
+	for e := max(n, -n); e > 0; e >>= 1 {
+		if e&1 == 1 {
+			result.Mul(result, base)
+		}
+		base.Mul(base, base)
+	}
+	if n < 0 {
+		result.Quo(a.newFloat().SetInt64(1), result)
+	}
+	return result
+}
+
+// realPow raises the positive number x to the power y, which need not be an
+// integer, as exp(y * ln(x)) with guard bits. It reports false when x is not
+// positive or the result is out of the range of big.Float, for the float64
+// fallback to handle.
+func (a bigArithmetic) realPow(x, y *big.Float) (*big.Float, bool) {
+	if x.Sign() <= 0 || x.IsInf() || y.IsInf() {
+		return nil, false
+	}
+	if y.Cmp(big.NewFloat(0.5)) == 0 {
+		return a.newFloat().Sqrt(x), true
+	}
+
+	prec := a.prec + 64
+	z := new(big.Float).SetPrec(prec).Mul(bigLn(x, prec), y)
+	power, ok := bigExp(z, prec)
+	if !ok {
+		return nil, false
+	}
+	return a.newFloat().Set(power), true
+}
+
+// bigLn returns the natural logarithm of the positive number x with prec
+// bits. x is split into m * 2^e with m in [0.5, 1), and ln(m) is summed as
+// 2 * atanh((m-1)/(m+1)).
+func bigLn(x *big.Float, prec uint) *big.Float {
+	m := new(big.Float).SetPrec(prec)
+	e := x.MantExp(m)
+
+	one := new(big.Float).SetPrec(prec).SetInt64(1)
+	t := new(big.Float).SetPrec(prec).Quo(
+		new(big.Float).SetPrec(prec).Sub(m, one),
+		new(big.Float).SetPrec(prec).Add(m, one))
+	ln := bigAtanh(t, prec)
+	ln.Mul(ln, big.NewFloat(2))
+	ln2 := bigLn2(prec)
+	return ln.Add(ln, ln2.Mul(ln2, new(big.Float).SetInt64(int64(e))))
+}
+
+// bigLn2 returns ln(2) = 2 * atanh(1/3) with prec bits.
+func bigLn2(prec uint) *big.Float {
+	ln2 := bigAtanh(new(big.Float).SetPrec(prec).Quo(big.NewFloat(1), big.NewFloat(3)), prec)
+	return ln2.Mul(ln2, big.NewFloat(2))
+}
+
+// bigAtanh sums the series t + t^3/3 + t^5/5 + ... with prec bits, for |t|
+// at most 1/3.
+func bigAtanh(t *big.Float, prec uint) *big.Float {
+	sum := new(big.Float).SetPrec(prec).Set(t)
+	if t.Sign() == 0 {
+		return sum
+	}
+	t2 := new(big.Float).SetPrec(prec).Mul(t, t)
+	power := new(big.Float).SetPrec(prec).Set(t)
+	term := new(big.Float).SetPrec(prec)
+	for k := int64(3); ; k += 2 {
+		power.Mul(power, t2)
+		term.Quo(power, new(big.Float).SetInt64(k))
+		if term.Sign() == 0 || term.MantExp(nil) < sum.MantExp(nil)-int(prec) {
+			return sum
+		}
+		sum.Add(sum, term)
+	}
+}
+
+// bigExp returns e^z with prec bits, and false when it is out of the range
+// of big.Float. z is reduced to r = z - n*ln(2) with |r| <= ln(2)/2 and
+// e^r is summed as a Taylor series.
+func bigExp(z *big.Float, prec uint) (*big.Float, bool) {
+	ln2 := bigLn2(prec)
+	quotient := new(big.Float).SetPrec(prec).Quo(z, ln2)
+	// Int64 saturates, so exponents too large for big.Float stay so.
+	n, _ := quotient.Add(quotient, big.NewFloat(0.5*float64(quotient.Sign()))).Int64()
+	if n > math.MaxInt32/2 || n < math.MinInt32/2 {
+		return nil, false
+	}
+	r := new(big.Float).SetPrec(prec).Sub(z, ln2.Mul(ln2, new(big.Float).SetInt64(n)))
+
+	sum := new(big.Float).SetPrec(prec).SetInt64(1)
+	term := new(big.Float).SetPrec(prec).SetInt64(1)
+	for k := int64(1); ; k++ {
+		term.Mul(term, r)
+		term.Quo(term, new(big.Float).SetInt64(k))
+		if term.Sign() == 0 || term.MantExp(nil) < -int(prec) {
+			break
+		}
+		sum.Add(sum, term)
+	}
+	return sum.SetMantExp(sum, int(n)), true
+}
+
+// roundTo applies floor, ceil or round (half away from zero) to x.
+func (a bigArithmetic) roundTo(name string, x BigValue) BigValue {
+	if x.Int != nil || x.Float.IsInf() {
+		return x
+	}
+
+	truncated, _ := x.Float.Int(nil)
+	fraction := a.newFloat().Sub(x.Float, a.newFloat().SetInt(truncated))
+
+	step := 0
+	switch name {
+	case "floor":
+		if fraction.Sign() < 0 {
+			step = -1
+		}
+	case "ceil":
+		if fraction.Sign() > 0 {
+			step = 1
+		}
+	case "round":
+		if fraction.Abs(fraction).Cmp(big.NewFloat(0.5)) >= 0 {
+			step = x.Float.Sign()
+		}
+	}
+	return a.fromInt(truncated.Add(truncated, big.NewInt(int64(step))))
+}
+
+// roundDigits rounds x half away from zero to the given number of decimal places.
+func (a bigArithmetic) roundDigits(x BigValue, digits int64) BigValue {
+	if x.Int != nil && digits >= 0 {
+		return x
+	}
+
+	factor := a.pow(a.newFloat().SetInt64(10), digits)
+	scaled := a.roundTo("round", a.fromBigFloat(a.newFloat().Mul(a.float(x), factor)))
+	return a.fromBigFloat(a.newFloat().Quo(a.float(scaled), factor))
+}
+
+func (a bigArithmetic) cmp(x, y BigValue) int {
+	if x.Int != nil && y.Int != nil {
+		return x.Int.Cmp(y.Int)
+	}
+	return a.float(x).Cmp(a.float(y))
+}
+
+// intBinary applies op to two integers, reporting whether the result is an exact integer.
+func intBinary(op lexer.TokenKind, x, y *big.Int) (*big.Int, bool) {
+	switch op {
+	case lexer.PLUS:
+		return new(big.Int).Add(x, y), true
+	case lexer.DASH:
+		return new(big.Int).Sub(x, y), true
+	case lexer.STAR:
+		return new(big.Int).Mul(x, y), true
+	case lexer.SLASH:
+		if y.Sign() == 0 {
+			return nil, false
+		}
+		quotient, remainder := new(big.Int).QuoRem(x, y, new(big.Int))
+		return quotient, remainder.Sign() == 0
+	case lexer.PERCENT:
+		if y.Sign() == 0 {
+			return nil, false
+		}
+		return remainder(x, y), true
+	case lexer.HAT:
+		if y.Sign() < 0 || !y.IsInt64() || y.Int64() > maxExactBits/max(int64(x.BitLen()), 1) {
+			return nil, false
+		}
+		return new(big.Int).Exp(x, y, nil), true
+	case lexer.AMPERSAND:
+		return new(big.Int).And(x, y), true
+	case lexer.PIPE:
+		return new(big.Int).Or(x, y), true
+	case lexer.XOR:
+		return new(big.Int).Xor(x, y), true
+	case lexer.SHIFT_LEFT:
+		if y.Sign() < 0 || !y.IsInt64() || y.Int64() > maxExactBits-int64(x.BitLen()) {
+			return nil, false
+		}
+		return new(big.Int).Lsh(x, uint(y.Int64())), true
+	case lexer.SHIFT_RIGHT:
+		if y.Sign() < 0 {
+			return nil, false
+		}
+		if !y.IsInt64() || y.Int64() > int64(x.BitLen()) {
+			return new(big.Int).Rsh(x, uint(x.BitLen())+1), true
+		}
+		return new(big.Int).Rsh(x, uint(y.Int64())), true
+	}
+	return nil, false
+}
+
+// remainder returns x - n*y where n is x/y rounded to the nearest integer,
+// ties to even, matching math.Remainder.
+func remainder(x, y *big.Int) *big.Int {
+	quotient, r := new(big.Int).QuoRem(x, y, new(big.Int))
+
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	if c := twice.CmpAbs(y); c > 0 || (c == 0 && quotient.Bit(0) == 1) {
+		if r.Sign()*y.Sign() > 0 {
+			quotient.Add(quotient, big.NewInt(1))
+		} else {
+			quotient.Sub(quotient, big.NewInt(1))
+		}
+	}
+	return new(big.Int).Sub(x, new(big.Int).Mul(quotient, y))
+}
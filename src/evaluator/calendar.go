@@ -0,0 +1,406 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package evaluator
+
+import (
+	"calculator/src/lexer"
+	"calculator/src/parser"
+	"calculator/src/units"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateValue is a value of the Float backend for a date, or a date and a time
+// of day, as in "2026-03-01" or "2026-03-01 09:30".
+type DateValue struct {
+	// Time is the date, in UTC so that every day has 24 hours.
+	Time time.Time
+	// Clock is set when the time of day is part of the value.
+	Clock bool
+}
+
+func (v DateValue) String() string {
+	return parser.FormatDate(v.Time, v.Clock)
+}
+
+// Float64 returns the seconds since 1970-01-01, which is how dates are
+// represented in float64 arithmetic.
+func (v DateValue) Float64() float64 {
+	return parser.UnixSeconds(v.Time)
+}
+
+// DurationValue is a value of the Float backend for a length of time, such as
+// "3h 45m" or the difference of two dates.
+type DurationValue struct {
+	// Seconds is the length of the duration.
+	Seconds float64
+}
+
+// String writes the duration in days, hours, minutes and seconds, leaving out
+// the ones that are zero, as in "68d" or "15h 30min".
+func (v DurationValue) String() string {
+	rest := significant(math.Abs(v.Seconds))
+	if math.IsInf(rest, 0) || math.IsNaN(rest) {
+		return fmt.Sprintf("%g s", v.Seconds)
+	}
+
+	var parts []string
+	for _, part := range []struct {
+		suffix  string
+		seconds float64
+	}{{"d", 86400}, {"h", 3600}, {"min", 60}} {
+		if count := math.Floor(rest / part.seconds); count > 0 {
+			parts = append(parts, strconv.FormatFloat(count, 'f', -1, 64)+part.suffix)
+			rest -= count * part.seconds
+		}
+	}
+	if rest = significant(rest); rest > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%gs", rest))
+	}
+
+	text := strings.Join(parts, " ")
+	if v.Seconds < 0 {
+		text = "-" + text
+	}
+	return text
+}
+
+// Float64 returns the length of the duration in seconds.
+func (v DurationValue) Float64() float64 {
+	return v.Seconds
+}
+
+// WeekdayValue is a day of the week, as returned by weekday.
+type WeekdayValue struct {
+	Day time.Weekday
+}
+
+func (v WeekdayValue) String() string {
+	return v.Day.String()
+}
+
+// Float64 returns the ISO 8601 number of the day, from 1 for Monday to 7 for
+// Sunday.
+func (v WeekdayValue) Float64() float64 {
+	if v.Day == time.Sunday {
+		return 7
+	}
+	return float64(v.Day)
+}
+
+// Names of the current date, without and with the time of day.
+const (
+	today = "today"
+	now   = "now"
+)
+
+// second is the unit durations are measured in.
+var second = func() units.Product {
+	unit, _ := units.Lookup("s")
+	return units.Of(unit)
+}()
+
+// errDateArithmetic is returned for an operation dates have no meaning for,
+// as in "2026-03-01 * 2" or the sum of two dates.
+var errDateArithmetic = errors.New("a date can only be moved by a length of time or subtracted from another date")
+
+// errDateRange is returned for a date moved beyond the years 0 to 9999 that a
+// date can be written with, as in "2026-03-01 + 10000000000000 yr".
+var errDateRange = &parser.CalendarError{Err: errors.New("the date is outside the years 0 to 9999")}
+
+// maxDateOffset bounds the seconds a date is moved by before the calendar
+// computes with them, so that neither int nor time.Time overflows.
+const maxDateOffset = 10000 * 366 * 24 * 60 * 60
+
+// calendarArithmetic implements Arithmetic over the values of the Float
+// backend for expressions with dates: DateValue, DurationValue, WeekdayValue,
+// and the QuantityValue numbers and quantities it evaluates like
+// quantityArithmetic does. A duration is a quantity of time that is shown in
+// days, hours, minutes and seconds, and stays one through sums, products and
+// quotients by numbers.
+type calendarArithmetic struct {
+	quantities quantityArithmetic
+}
+
+// NewCalendarArithmetic creates the arithmetic the Float backend uses for
+// expressions with dates or durations, reading the angle mode from env.
+func NewCalendarArithmetic(env *parser.Env) Arithmetic[Result] {
+	return calendarArithmetic{quantities: quantityArithmetic{env: env}}
+}
+
+func (a calendarArithmetic) Literal(n parser.NumberExpr) (Result, error) {
+	return a.quantities.Literal(n)
+}
+
+func (a calendarArithmetic) FromFloat(x float64) (Result, error) {
+	return a.quantities.FromFloat(x)
+}
+
+func (a calendarArithmetic) Constant(name string) (Result, bool) {
+	return a.quantities.Constant(name)
+}
+
+func (a calendarArithmetic) Date(n parser.DateExpr) Result {
+	return DateValue{Time: n.Value, Clock: n.Clock}
+}
+
+func (a calendarArithmetic) Duration(n parser.DurationExpr) Result {
+	return DurationValue{Seconds: n.Seconds}
+}
+
+// Moment returns the current date for "today" and the current date and time
+// for "now", both as shown by the local clock.
+func (a calendarArithmetic) Moment(name string) (Result, bool) {
+	local := time.Now()
+	year, month, day := local.Date()
+	switch name {
+	case today:
+		return DateValue{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}, true
+	case now:
+		hour, minute, sec := local.Clock()
+		return DateValue{Time: time.Date(year, month, day, hour, minute, sec, 0, time.UTC), Clock: true}, true
+	}
+	return nil, false
+}
+
+func (a calendarArithmetic) Unit(name string) (Result, bool) {
+	return a.quantities.Unit(name)
+}
+
+func (a calendarArithmetic) Quantity(value Result, unit string) (Result, error) {
+	x, err := quantity(value)
+	if err != nil {
+		return nil, err
+	}
+	return a.quantities.Quantity(x, unit)
+}
+
+func (a calendarArithmetic) Convert(value Result, unit string) (Result, error) {
+	x, err := quantity(value)
+	if err != nil {
+		return nil, err
+	}
+	return a.quantities.Convert(x, unit)
+}
+
+func (a calendarArithmetic) Binary(op lexer.TokenKind, x, y Result) (Result, error) {
+	xDate, xIsDate := x.(DateValue)
+	yDate, yIsDate := y.(DateValue)
+	switch {
+	case xIsDate && yIsDate && op == lexer.DASH:
+		return DurationValue{Seconds: xDate.Float64() - yDate.Float64()}, nil
+	case xIsDate && !yIsDate && op == lexer.PLUS:
+		return moved(xDate, y, 1)
+	case xIsDate && !yIsDate && op == lexer.DASH:
+		return moved(xDate, y, -1)
+	case yIsDate && !xIsDate && op == lexer.PLUS:
+		return moved(yDate, x, 1)
+	case xIsDate || yIsDate:
+		return nil, errDateArithmetic
+	}
+
+	qx, err := quantity(x)
+	if err != nil {
+		return nil, err
+	}
+	qy, err := quantity(y)
+	if err != nil {
+		return nil, err
+	}
+	value, err := a.quantities.Binary(op, qx, qy)
+	return timed(value, x, y), err
+}
+
+func (a calendarArithmetic) Unary(op lexer.TokenKind, x Result) (Result, error) {
+	qx, err := quantity(x)
+	if err != nil {
+		return nil, err
+	}
+	value, err := a.quantities.Unary(op, qx)
+	return timed(value, x), err
+}
+
+func (a calendarArithmetic) Postfix(op lexer.TokenKind, x Result) (Result, error) {
+	qx, err := quantity(x)
+	if err != nil {
+		return nil, err
+	}
+	value, err := a.quantities.Postfix(op, qx)
+	return timed(value, x), err
+}
+
+// Call computes the calendar functions on dates, and the others like
+// quantityArithmetic does.
+func (a calendarArithmetic) Call(name string, args []Result) (Result, error) {
+	switch name {
+	case "weekday":
+		date, err := dateArgument(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		return WeekdayValue{Day: date.Time.Weekday()}, nil
+	case "workday":
+		date, err := dateArgument(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		days, err := quantity(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if len(days.Unit) > 0 || days.Value != math.Trunc(days.Value) {
+			return nil, fmt.Errorf("workday expects a whole number of days but had %s", days)
+		}
+		return DateValue{Time: parser.AddWorkdays(date.Time, int(days.Value)), Clock: date.Clock}, nil
+	case "workdays":
+		from, err := dateArgument(name, args[0])
+		if err != nil {
+			return nil, err
+		}
+		to, err := dateArgument(name, args[1])
+		if err != nil {
+			return nil, err
+		}
+		return QuantityValue{Value: float64(parser.CountWorkdays(from.Time, to.Time))}, nil
+	}
+
+	values := make([]QuantityValue, len(args))
+	for i, arg := range args {
+		value, err := quantity(arg)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	value, err := a.quantities.Call(name, values)
+	return timed(value, args...), err
+}
+
+// moved returns date moved by amount, a length of time, forwards when sign is
+// 1 and backwards when it is -1. Whole numbers of days, weeks, months and
+// years move it on the calendar: a month after January 31 is the last day of
+// February, and a year after February 29 is February 28.
+func moved(date DateValue, amount Result, sign int) (Result, error) {
+	x, err := quantity(amount)
+	if err != nil {
+		return nil, err
+	}
+	if !units.Convertible(x.Unit, second) {
+		return nil, fmt.Errorf("only a length of time can be added to a date but had %s", x)
+	}
+	if !(math.Abs(x.Value*x.Unit.Scale()) <= maxDateOffset) {
+		return nil, errDateRange
+	}
+
+	var result DateValue
+	calendar := false
+	if len(x.Unit) == 1 && x.Unit[0].Power == 1 && x.Value == math.Trunc(x.Value) {
+		n := sign * int(x.Value)
+		calendar = true
+		switch x.Unit[0].Unit.Name {
+		case "day":
+			result = DateValue{Time: date.Time.AddDate(0, 0, n), Clock: date.Clock}
+		case "week":
+			result = DateValue{Time: date.Time.AddDate(0, 0, 7*n), Clock: date.Clock}
+		case "month":
+			result = DateValue{Time: addMonths(date.Time, n), Clock: date.Clock}
+		case "yr":
+			result = DateValue{Time: addMonths(date.Time, 12*n), Clock: date.Clock}
+		default:
+			calendar = false
+		}
+	}
+
+	if !calendar {
+		// Rounded to microseconds, which hides the error of float64 seconds.
+		seconds := date.Float64() + float64(sign)*x.Value*x.Unit.Scale()
+		moved := parser.UnixDate(math.Round(seconds*1e6) / 1e6)
+		hour, minute, sec := moved.Clock()
+		result = DateValue{Time: moved, Clock: date.Clock || hour != 0 || minute != 0 || sec != 0}
+	}
+	if year := result.Time.Year(); year < 0 || year > 9999 {
+		return nil, errDateRange
+	}
+	return result, nil
+}
+
+// addMonths returns date n months later, on the last day of that month when
+// it is shorter than the day of date.
+func addMonths(date time.Time, n int) time.Time {
+	year, month, day := date.Date()
+	first := time.Date(year, month+time.Month(n), 1, date.Hour(), date.Minute(), date.Second(), date.Nanosecond(), time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, last)-1)
+}
+
+// quantity returns value as the QuantityValue quantityArithmetic computes
+// with: a duration is a number of seconds, and a date is not a quantity.
+func quantity(value Result) (QuantityValue, error) {
+	switch v := value.(type) {
+	case QuantityValue:
+		return v, nil
+	case DurationValue:
+		return QuantityValue{Value: v.Seconds, Unit: second}, nil
+	case DateValue, WeekdayValue:
+		return QuantityValue{}, fmt.Errorf("expected a number but had %s", value)
+	default:
+		// A variable assigned by another backend.
+		return QuantityValue{Value: value.Float64()}, nil
+	}
+}
+
+// timed returns value computed from operands as a duration when one of them
+// is a duration and value is still a length of time, so that "3h 45m * 4" is
+// a duration while "3h 45m / 15min" is a number.
+func timed(value QuantityValue, operands ...Result) Result {
+	if len(value.Unit) == 0 || !units.Convertible(value.Unit, second) {
+		return value
+	}
+	for _, operand := range operands {
+		if _, isDuration := operand.(DurationValue); isDuration {
+			return DurationValue{Seconds: value.Value * value.Unit.Scale()}
+		}
+	}
+	return value
+}
+
+// dateArgument returns the argument of the function name that must be a date.
+func dateArgument(name string, arg Result) (DateValue, error) {
+	date, isDate := arg.(DateValue)
+	if !isDate {
+		return DateValue{}, fmt.Errorf("%s expects a date but had %s", name, arg)
+	}
+	return date, nil
+}
+
+// usesCalendar reports whether expr involves dates or durations: a date or
+// duration literal, "today" or "now", a calendar function, or a variable
+// holding a date, duration or weekday. The Float backend evaluates only those
+// expressions with calendarArithmetic.
+func usesCalendar(expr parser.Expr, env *parser.Env) bool {
+	return anyNode(expr, func(expr parser.Expr) bool {
+		switch n := expr.(type) {
+		case parser.DateExpr, parser.DurationExpr:
+			return true
+		case parser.CallExpr:
+			return n.Name == "weekday" || n.Name == "workday" || n.Name == "workdays"
+		case parser.IdentifierExpr:
+			if exact, exists := env.GetExact(n.Name); exists {
+				switch exact.(type) {
+				case DateValue, DurationValue, WeekdayValue:
+					return true
+				}
+				return false
+			}
+			if _, exists := env.Get(n.Name); exists {
+				return false
+			}
+			return n.Name == today || n.Name == now
+		}
+		return false
+	})
+}
@@ -0,0 +1,308 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package evaluator
+
+import (
+	"calculator/src/lexer"
+	"calculator/src/parser"
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+)
+
+// ComplexFormat controls how a complex result is displayed.
+type ComplexFormat int
+
+const (
+	// Rectangular shows the real and imaginary parts, such as "3+4i".
+	Rectangular ComplexFormat = iota
+	// Polar shows the magnitude and the angle in degrees, such as "5∠53.13°".
+	Polar
+)
+
+// ComplexFormats lists every ComplexFormat in the order the GUI cycles through them.
+var ComplexFormats = []ComplexFormat{Rectangular, Polar}
+
+// String returns the short indicator shown for the format.
+func (f ComplexFormat) String() string {
+	switch f {
+	case Rectangular:
+		return "x+yi"
+	case Polar:
+		return "r∠θ"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", int(f))
+	}
+}
+
+// ParseComplexFormat returns the ComplexFormat whose indicator is s.
+func ParseComplexFormat(s string) (ComplexFormat, error) {
+	for _, format := range ComplexFormats {
+		if format.String() == s {
+			return format, nil
+		}
+	}
+	return Rectangular, fmt.Errorf("unknown complex format %q", s)
+}
+
+// Next returns the format that follows f in ComplexFormats.
+func (f ComplexFormat) Next() ComplexFormat {
+	for i, format := range ComplexFormats {
+		if format == f {
+			return ComplexFormats[(i+1)%len(ComplexFormats)]
+		}
+	}
+	return ComplexFormats[0]
+}
+
+// ComplexValue is a value of the Complex backend.
+type ComplexValue complex128
+
+func (v ComplexValue) String() string {
+	return v.Format(Rectangular)
+}
+
+// Float64 returns the value when it is real, and NaN otherwise.
+func (v ComplexValue) Float64() float64 {
+	if imag(v) != 0 {
+		return math.NaN()
+	}
+	return real(v)
+}
+
+// Format returns the value displayed in format f.
+func (v ComplexValue) Format(f ComplexFormat) string {
+	re, im := real(v), imag(v)
+	if f == Polar {
+		angle := strconv.FormatFloat(math.Round(cmplx.Phase(complex128(v))*180/math.Pi*100)/100, 'f', -1, 64)
+		return fmt.Sprintf("%g∠%s°", cmplx.Abs(complex128(v)), angle)
+	}
+
+	switch {
+	case im == 0:
+		return fmt.Sprintf("%g", re)
+	case re == 0:
+		return imaginaryString(im)
+	case im < 0:
+		return fmt.Sprintf("%g-%s", re, imaginaryString(-im))
+	default:
+		return fmt.Sprintf("%g+%s", re, imaginaryString(im))
+	}
+}
+
+// imaginaryString formats im as a multiple of the imaginary unit, writing
+// "i" rather than "1i".
+func imaginaryString(im float64) string {
+	switch im {
+	case 1:
+		return parser.ImaginaryUnit
+	case -1:
+		return "-" + parser.ImaginaryUnit
+	default:
+		return fmt.Sprintf("%g%s", im, parser.ImaginaryUnit)
+	}
+}
+
+// complexFunctions are the builtins that accept complex arguments, keyed by name.
+// The trigonometric ones receive their argument already converted to radians
+// and return radians.
+var complexFunctions = map[string]func(complex128) complex128{
+	"re":   func(z complex128) complex128 { return complex(real(z), 0) },
+	"im":   func(z complex128) complex128 { return complex(imag(z), 0) },
+	"conj": cmplx.Conj,
+	"abs":  func(z complex128) complex128 { return complex(cmplx.Abs(z), 0) },
+	"arg":  func(z complex128) complex128 { return complex(cmplx.Phase(z), 0) },
+	"sqrt": cmplx.Sqrt,
+	"ln":   cmplx.Log,
+	"exp":  cmplx.Exp,
+	"sin":  cmplx.Sin,
+	"cos":  cmplx.Cos,
+	"tan":  cmplx.Tan,
+	"asin": cmplx.Asin,
+	"acos": cmplx.Acos,
+	"atan": cmplx.Atan,
+}
+
+// complexArithmetic implements Arithmetic over ComplexValue.
+// Operations on real operands with a real result are computed exactly like
+// the Float backend does; the others use math/cmplx.
+type complexArithmetic struct {
+	env *parser.Env
+}
+
+// NewComplexArithmetic creates the Complex backend arithmetic, reading the
+// angle mode from env.
+func NewComplexArithmetic(env *parser.Env) Arithmetic[ComplexValue] {
+	return complexArithmetic{env: env}
+}
+
+func (a complexArithmetic) ImaginaryUnit() ComplexValue {
+	return ComplexValue(complex(0, 1))
+}
+
+func (a complexArithmetic) Literal(n parser.NumberExpr) (ComplexValue, error) {
+	x, err := n.Float64()
+	if err != nil {
+		return 0, err
+	}
+	return a.FromFloat(x)
+}
+
+func (a complexArithmetic) FromFloat(x float64) (ComplexValue, error) {
+	if math.IsNaN(x) {
+		return 0, errNotReal
+	}
+	return ComplexValue(complex(x, 0)), nil
+}
+
+func (a complexArithmetic) Binary(op lexer.TokenKind, x, y ComplexValue) (ComplexValue, error) {
+	if imag(x) == 0 && imag(y) == 0 {
+		if result, err := parser.BinaryOp(op, real(x), real(y)); err != nil || !math.IsNaN(result) {
+			return ComplexValue(complex(result, 0)), err
+		}
+	}
+
+	z, w := complex128(x), complex128(y)
+	switch op {
+	case lexer.PLUS:
+		return clean(z + w)
+	case lexer.DASH:
+		return clean(z - w)
+	case lexer.STAR:
+		return clean(z * w)
+	case lexer.SLASH:
+		if w == 0 {
+			return 0, errDivisionByZero
+		}
+		return clean(z / w)
+	case lexer.HAT:
+		return clean(pow(z, w))
+	case lexer.ROOT:
+		if w == 0 {
+			return 0, errDivisionByZero
+		}
+		return clean(pow(z, 1/w))
+	case lexer.LOG:
+		return clean(cmplx.Log(z) / cmplx.Log(w))
+	default:
+		return 0, fmt.Errorf("operator %s is not defined for complex numbers", lexer.TokenKindString(op))
+	}
+}
+
+func (a complexArithmetic) Unary(op lexer.TokenKind, x ComplexValue) (ComplexValue, error) {
+	if op == lexer.DASH {
+		return clean(-complex128(x))
+	}
+	return 0, fmt.Errorf("operator %s not recognized", lexer.TokenKindString(op))
+}
+
+func (a complexArithmetic) Postfix(op lexer.TokenKind, x ComplexValue) (ComplexValue, error) {
+	switch op {
+	case lexer.PERCENT:
+		return x / 100, nil
+	case lexer.DEGREE:
+		return clean(complex128(x) * complex(a.env.Angle.FromRadians(parser.Degrees.ToRadians(1)), 0))
+	}
+
+	if imag(x) != 0 {
+		return 0, fmt.Errorf("operator %s is not defined for complex numbers", lexer.TokenKindString(op))
+	}
+	result, err := parser.PostfixOp(a.env, op, real(x))
+	if err != nil {
+		return 0, err
+	}
+	return a.FromFloat(result)
+}
+
+func (a complexArithmetic) Call(name string, args []ComplexValue) (ComplexValue, error) {
+	reals := make([]float64, len(args))
+	for i, arg := range args {
+		reals[i] = arg.Float64()
+	}
+	fn, isComplex := complexFunctions[name]
+
+	if !containsNaN(reals) {
+		result, err := parser.CallFunction(a.env, name, reals)
+		if err != nil {
+			return 0, err
+		}
+		if !math.IsNaN(result) {
+			return ComplexValue(complex(result, 0)), nil
+		}
+		if !isComplex {
+			return 0, errNotReal
+		}
+	}
+	if !isComplex {
+		return 0, fmt.Errorf("%s is not defined for complex numbers", name)
+	}
+
+	z := complex128(args[0])
+	switch name {
+	case "sin", "cos", "tan":
+		return clean(fn(z * complex(a.env.Angle.ToRadians(1), 0)))
+	case "asin", "acos", "atan", "arg":
+		return clean(fn(z) * complex(a.env.Angle.FromRadians(1), 0))
+	default:
+		return clean(fn(z))
+	}
+}
+
+// containsNaN reports whether any value is NaN, which Float64 returns
+// for values that are not real.
+func containsNaN(values []float64) bool {
+	for _, value := range values {
+		if math.IsNaN(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxIntegerPower is the largest exponent pow computes by repeated
+// multiplication, which is exact for Gaussian integers such as (3+4i)^2.
+const maxIntegerPower = 64
+
+// pow raises z to the power w.
+func pow(z, w complex128) complex128 {
+	if imag(w) != 0 || real(w) != math.Trunc(real(w)) || math.Abs(real(w)) > maxIntegerPower {
+		return cmplx.Pow(z, w)
+	}
+
+	n := int(real(w))
+	result := complex(1, 0)
+	for range max(n, -n) {
+		result *= z
+	}
+	if n < 0 {
+		return 1 / result
+	}
+	return result
+}
+
+// errNotComplex is returned when a result is not a number, such as 0*Inf.
+var errNotComplex = errors.New("result is not a number")
+
+// clean removes the rounding noise math/cmplx leaves in a component that is
+// negligible next to the other one, so that sqrt(-4) is 2i rather than
+// 1.2e-16+2i.
+func clean(z complex128) (ComplexValue, error) {
+	if cmplx.IsNaN(z) {
+		return 0, errNotComplex
+	}
+
+	const epsilon = 1e-14
+	re, im := real(z), imag(z)
+	if math.Abs(re) < epsilon*math.Abs(im) {
+		re = 0
+	}
+	if math.Abs(im) < epsilon*math.Abs(re) {
+		im = 0
+	}
+	// Adding zero turns -0 into 0, which would otherwise put the results of
+	// functions such as sqrt on the wrong side of their branch cut: sqrt(-4)
+	// is 2i, not -2i.
+	return ComplexValue(complex(re+0, im+0)), nil
+}
@@ -0,0 +1,377 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package evaluator
+
+import (
+	"calculator/src/lexer"
+	"calculator/src/parser"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode selects how a decimal is rounded to fewer decimal places.
+type RoundingMode int
+
+const (
+	// HalfEven rounds to the nearest value and ties to an even last digit,
+	// as bankers do: 0.125 becomes 0.12.
+	HalfEven RoundingMode = iota
+	// HalfUp rounds to the nearest value and ties away from zero: 0.125 becomes 0.13.
+	HalfUp
+	// Down truncates towards zero: 0.129 becomes 0.12.
+	Down
+)
+
+// RoundingModes lists every RoundingMode in the order they are offered to the user.
+var RoundingModes = []RoundingMode{HalfEven, HalfUp, Down}
+
+// String returns the name the rounding mode is shown with.
+func (m RoundingMode) String() string {
+	switch m {
+	case HalfEven:
+		return "half-even"
+	case HalfUp:
+		return "half-up"
+	case Down:
+		return "down"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", int(m))
+	}
+}
+
+// ParseRoundingMode returns the RoundingMode whose name is s.
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	for _, mode := range RoundingModes {
+		if mode.String() == s {
+			return mode, nil
+		}
+	}
+	return HalfEven, fmt.Errorf("unknown rounding mode %q", s)
+}
+
+// DefaultScale is the number of decimal places the Decimal backend shows
+// when none is configured.
+const DefaultScale = 2
+
+// divisionGuard is the number of decimal places beyond the configured scale
+// quotients and other inexact results are computed with, so that "1/3*3"
+// still rounds to 1.
+const divisionGuard = 16
+
+// DecimalValue is a value of the Decimal backend: Coef / 10^Scale.
+// Sums, differences and products are exact; quotients are rounded to
+// divisionGuard places more than the displayed scale.
+type DecimalValue struct {
+	// Coef is the value without its decimal point.
+	Coef *big.Int
+	// Scale is the number of decimal places in Coef.
+	Scale int
+	// display and mode set how String rounds the value.
+	display int
+	mode    RoundingMode
+}
+
+// String returns the value rounded to the configured scale, with exactly
+// that many decimal places as in "3.30".
+func (v DecimalValue) String() string {
+	rounded := roundDecimal(v, v.display, v.mode)
+	return rounded.Text()
+}
+
+// Text returns the exact value with all of its decimal places.
+func (v DecimalValue) Text() string {
+	digits := new(big.Int).Abs(v.Coef).String()
+	sign := ""
+	if v.Coef.Sign() < 0 {
+		sign = "-"
+	}
+	if v.Scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= v.Scale {
+		digits = strings.Repeat("0", v.Scale-len(digits)+1) + digits
+	}
+	point := len(digits) - v.Scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+func (v DecimalValue) Float64() float64 {
+	value, _ := strconv.ParseFloat(v.Text(), 64)
+	return value
+}
+
+// decimalArithmetic implements Arithmetic over DecimalValue.
+type decimalArithmetic struct {
+	scale int
+	mode  RoundingMode
+	env   *parser.Env
+}
+
+// NewDecimalArithmetic creates the Decimal backend arithmetic showing results
+// with scale decimal places rounded with mode, reading the angle mode from env.
+func NewDecimalArithmetic(scale int, mode RoundingMode, env *parser.Env) Arithmetic[DecimalValue] {
+	return decimalArithmetic{scale: max(scale, 0), mode: mode, env: env}
+}
+
+// maxDecimalScale bounds the exponents and the decimal places of values
+// computed exactly, as maxExactBits does for their digits. Literals and
+// products beyond it are approximated with float64 instead.
+const maxDecimalScale = maxExactBits / 4
+
+func (a decimalArithmetic) decimal(coef *big.Int, scale int) DecimalValue {
+	return DecimalValue{Coef: coef, Scale: scale, display: a.scale, mode: a.mode}
+}
+
+func (a decimalArithmetic) Literal(n parser.NumberExpr) (DecimalValue, error) {
+	if n.Literal == "" {
+		return a.FromFloat(n.Value)
+	}
+
+	digits, base := parser.SplitNumber(n.Literal)
+	if base != 10 {
+		i, ok := new(big.Int).SetString(digits, base)
+		if !ok {
+			return DecimalValue{}, errors.New("invalid number " + n.Literal)
+		}
+		return a.decimal(i, 0), nil
+	}
+	return a.parse(digits)
+}
+
+// FromFloat converts x through its shortest decimal representation, so that
+// 0.1 becomes exactly 0.1.
+func (a decimalArithmetic) FromFloat(x float64) (DecimalValue, error) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return DecimalValue{}, errNotReal
+	}
+	return a.parse(strconv.FormatFloat(x, 'g', -1, 64))
+}
+
+// parse converts a decimal such as "12.50" or "1.5e-3" exactly, or through
+// float64 when its exponent is beyond maxDecimalScale.
+func (a decimalArithmetic) parse(s string) (DecimalValue, error) {
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return DecimalValue{}, err
+		}
+		mantissa, exponent = s[:i], e
+	}
+	if exponent > maxDecimalScale || exponent < -maxDecimalScale {
+		x, _ := strconv.ParseFloat(s, 64)
+		return a.FromFloat(x)
+	}
+
+	scale := 0
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		scale = len(mantissa) - i - 1
+		mantissa = mantissa[:i] + mantissa[i+1:]
+	}
+	coef, ok := new(big.Int).SetString(mantissa, 10)
+	if !ok {
+		return DecimalValue{}, errors.New("invalid number " + s)
+	}
+
+	scale -= exponent
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+	return a.decimal(coef, scale), nil
+}
+
+func (a decimalArithmetic) Binary(op lexer.TokenKind, x, y DecimalValue) (DecimalValue, error) {
+	switch op {
+	case lexer.PLUS:
+		x, y := align(x, y)
+		return a.decimal(new(big.Int).Add(x.Coef, y.Coef), x.Scale), nil
+	case lexer.DASH:
+		x, y := align(x, y)
+		return a.decimal(new(big.Int).Sub(x.Coef, y.Coef), x.Scale), nil
+	case lexer.STAR:
+		if x.Scale+y.Scale <= maxDecimalScale {
+			return a.decimal(new(big.Int).Mul(x.Coef, y.Coef), x.Scale+y.Scale), nil
+		}
+	case lexer.SLASH:
+		return a.divide(x, y)
+	case lexer.PERCENT:
+		if y.Coef.Sign() == 0 {
+			return DecimalValue{}, errDivisionByZero
+		}
+		x, y := align(x, y)
+		return a.decimal(remainder(x.Coef, y.Coef), x.Scale), nil
+	case lexer.HAT:
+		if y.Scale == 0 || new(big.Int).Rem(y.Coef, pow10(y.Scale)).Sign() == 0 {
+			n := new(big.Int).Quo(y.Coef, pow10(y.Scale))
+			limit := maxExactBits / max(int64(x.Coef.BitLen()+x.Scale*4), 1)
+			if n.IsInt64() && n.Int64() <= limit && n.Int64() >= -limit {
+				e := new(big.Int).Abs(n)
+				power := a.decimal(new(big.Int).Exp(x.Coef, e, nil), x.Scale*int(e.Int64()))
+				if n.Sign() < 0 {
+					return a.divide(a.decimal(big.NewInt(1), 0), power)
+				}
+				return power, nil
+			}
+		}
+	}
+
+	return a.approximate(func(args []float64) (float64, error) {
+		return parser.BinaryOp(op, args[0], args[1])
+	}, x, y)
+}
+
+func (a decimalArithmetic) Unary(op lexer.TokenKind, x DecimalValue) (DecimalValue, error) {
+	if op == lexer.DASH {
+		return a.decimal(new(big.Int).Neg(x.Coef), x.Scale), nil
+	}
+
+	return a.approximate(func(args []float64) (float64, error) {
+		return parser.UnaryOp(op, args[0])
+	}, x)
+}
+
+func (a decimalArithmetic) Postfix(op lexer.TokenKind, x DecimalValue) (DecimalValue, error) {
+	switch op {
+	case lexer.BANG:
+		if x.Scale == 0 && x.Coef.Sign() >= 0 && x.Coef.IsInt64() && x.Coef.Int64() <= maxExactBits/32 {
+			return a.decimal(new(big.Int).MulRange(1, x.Coef.Int64()), 0), nil
+		}
+	case lexer.PERCENT:
+		return a.decimal(x.Coef, x.Scale+2), nil
+	}
+
+	return a.approximate(func(args []float64) (float64, error) {
+		return parser.PostfixOp(a.env, op, args[0])
+	}, x)
+}
+
+func (a decimalArithmetic) Call(name string, args []DecimalValue) (DecimalValue, error) {
+	switch name {
+	case "abs":
+		return a.decimal(new(big.Int).Abs(args[0].Coef), args[0].Scale), nil
+	case "floor", "ceil":
+		return floorDecimal(args[0], name == "ceil"), nil
+	case "round":
+		// round uses the configured rounding mode, so it matches what is displayed.
+		places := 0
+		if len(args) == 2 {
+			n := new(big.Int).Quo(args[1].Coef, pow10(args[1].Scale))
+			if !n.IsInt64() || n.CmpAbs(big.NewInt(maxExactBits)) >= 0 {
+				break
+			}
+			places = int(n.Int64())
+		}
+		return roundDecimal(args[0], places, a.mode), nil
+	case "min", "max":
+		result := args[0]
+		for _, arg := range args[1:] {
+			x, y := align(arg, result)
+			if c := x.Coef.Cmp(y.Coef); (name == "min" && c < 0) || (name == "max" && c > 0) {
+				result = arg
+			}
+		}
+		return result, nil
+	}
+
+	return a.approximate(func(floats []float64) (float64, error) {
+		return parser.CallFunction(a.env, name, floats)
+	}, args...)
+}
+
+// divide returns x / y rounded with the configured mode to divisionGuard
+// places more than the displayed scale, or to the places of the operands
+// when they have more.
+func (a decimalArithmetic) divide(x, y DecimalValue) (DecimalValue, error) {
+	if y.Coef.Sign() == 0 {
+		return DecimalValue{}, errDivisionByZero
+	}
+
+	// x/y * 10^scale = x.Coef * 10^(scale - x.Scale + y.Scale) / y.Coef
+	scale := max(a.scale+divisionGuard, x.Scale, y.Scale)
+	numerator := new(big.Int).Mul(x.Coef, pow10(scale-x.Scale+y.Scale))
+	quotient, rem := new(big.Int).QuoRem(numerator, y.Coef, new(big.Int))
+
+	if a.mode != Down && rem.Sign() != 0 {
+		twice := new(big.Int).Lsh(new(big.Int).Abs(rem), 1)
+		c := twice.CmpAbs(y.Coef)
+		if c > 0 || (c == 0 && (a.mode == HalfUp || quotient.Bit(0) == 1)) {
+			quotient.Add(quotient, big.NewInt(int64(x.Coef.Sign()*y.Coef.Sign())))
+		}
+	}
+	return a.decimal(quotient, scale), nil
+}
+
+// approximate computes fn over the float64 approximations of args.
+func (a decimalArithmetic) approximate(fn func(args []float64) (float64, error), args ...DecimalValue) (DecimalValue, error) {
+	floats := make([]float64, len(args))
+	for i, arg := range args {
+		floats[i] = arg.Float64()
+	}
+
+	result, err := fn(floats)
+	if err != nil {
+		return DecimalValue{}, err
+	}
+	return a.FromFloat(result)
+}
+
+// pow10 returns 10^n.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// align returns x and y with the same scale, the larger of theirs.
+func align(x, y DecimalValue) (DecimalValue, DecimalValue) {
+	switch {
+	case x.Scale < y.Scale:
+		x = DecimalValue{new(big.Int).Mul(x.Coef, pow10(y.Scale-x.Scale)), y.Scale, x.display, x.mode}
+	case y.Scale < x.Scale:
+		y = DecimalValue{new(big.Int).Mul(y.Coef, pow10(x.Scale-y.Scale)), x.Scale, y.display, y.mode}
+	}
+	return x, y
+}
+
+// roundDecimal rounds v to scale decimal places with mode. Negative scales
+// round to tens, hundreds and so on. Values with fewer places are returned
+// padded to scale places.
+func roundDecimal(v DecimalValue, scale int, mode RoundingMode) DecimalValue {
+	if v.Scale <= scale {
+		if scale < 0 {
+			return v
+		}
+		return DecimalValue{new(big.Int).Mul(v.Coef, pow10(scale-v.Scale)), scale, v.display, v.mode}
+	}
+
+	divisor := pow10(v.Scale - scale)
+	quotient, rem := new(big.Int).QuoRem(v.Coef, divisor, new(big.Int))
+
+	if mode != Down && rem.Sign() != 0 {
+		twice := new(big.Int).Lsh(new(big.Int).Abs(rem), 1)
+		c := twice.Cmp(divisor)
+		if c > 0 || (c == 0 && (mode == HalfUp || quotient.Bit(0) == 1)) {
+			quotient.Add(quotient, big.NewInt(int64(v.Coef.Sign())))
+		}
+	}
+
+	if scale < 0 {
+		return DecimalValue{quotient.Mul(quotient, pow10(-scale)), 0, v.display, v.mode}
+	}
+	return DecimalValue{quotient, scale, v.display, v.mode}
+}
+
+// floorDecimal returns the largest integer not above v, or the smallest not
+// below it when ceil is set.
+func floorDecimal(v DecimalValue, ceil bool) DecimalValue {
+	quotient, rem := new(big.Int).QuoRem(v.Coef, pow10(v.Scale), new(big.Int))
+	if !ceil && rem.Sign() < 0 {
+		quotient.Sub(quotient, big.NewInt(1))
+	}
+	if ceil && rem.Sign() > 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	return DecimalValue{quotient, 0, v.display, v.mode}
+}
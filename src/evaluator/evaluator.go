@@ -0,0 +1,480 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+
+// Package evaluator computes the value of parsed expressions with number types
+// other than the float64 used by parser.Expr.Eval. Every backend walks the same
+// AST, so the language is the same whichever one is selected.
+package evaluator
+
+import (
+	"calculator/src/lexer"
+	"calculator/src/parser"
+	"fmt"
+)
+
+// Backend selects the number type an expression is evaluated with.
+type Backend int
+
+const (
+	// Float evaluates with float64 through parser.Expr.Eval, with
+	// QuantityValue when the expression has units, and with DateValue and
+	// DurationValue as well when it has dates or durations.
+	Float Backend = iota
+	// Big evaluates with math/big: integers stay exact big.Int values and
+	// everything else is a big.Float with a configurable precision.
+	Big
+	// Rational evaluates with math/big rationals: results stay exact fractions
+	// until an operation with an irrational result is applied.
+	Rational
+	// Complex evaluates with complex128, so roots and logarithms of negative
+	// numbers have results and the imaginary unit "i" can be used.
+	Complex
+	// Decimal evaluates with base-10 decimals, so amounts such as 0.1 are
+	// exact and results are rounded to a fixed number of decimal places.
+	Decimal
+	// Interval evaluates with intervals of float64 bounds rounded outwards,
+	// so the result is guaranteed to contain the exact value.
+	Interval
+	// Uncertain evaluates with measurements that carry a standard uncertainty,
+	// such as "12.3 ± 0.1", propagated to first order.
+	Uncertain
+	// Programmer evaluates with integers of 8, 16, 32 or 64 bits that wrap
+	// around on overflow, and shows them in several bases at once.
+	Programmer
+)
+
+// Backends lists every Backend in the order they are offered to the user.
+var Backends = []Backend{Float, Big, Rational, Complex, Decimal, Interval, Uncertain, Programmer}
+
+// String returns the name the backend is shown with.
+func (b Backend) String() string {
+	switch b {
+	case Float:
+		return "Float"
+	case Big:
+		return "Big"
+	case Rational:
+		return "Rational"
+	case Complex:
+		return "Complex"
+	case Decimal:
+		return "Decimal"
+	case Interval:
+		return "Interval"
+	case Uncertain:
+		return "Uncertain"
+	case Programmer:
+		return "Programmer"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", int(b))
+	}
+}
+
+// ParseBackend returns the Backend whose name is s.
+func ParseBackend(s string) (Backend, error) {
+	for _, backend := range Backends {
+		if backend.String() == s {
+			return backend, nil
+		}
+	}
+	return Float, fmt.Errorf("unknown backend %q", s)
+}
+
+// DefaultPrecision is the number of significant digits used by the Big
+// backend when none is configured.
+const DefaultPrecision = 50
+
+// Options selects and configures the backend an expression is evaluated with.
+type Options struct {
+	// Backend is the number type used for the evaluation.
+	Backend Backend
+	// Precision is the number of significant decimal digits the Big backend
+	// computes and displays non-integer results with, and the Rational backend
+	// displays decimals with.
+	Precision uint
+	// Scale is the number of decimal places the Decimal backend shows results with.
+	Scale int
+	// Rounding is how the Decimal backend rounds results to Scale places.
+	Rounding RoundingMode
+	// WordSize is the number of bits of the integers of the Programmer
+	// backend, one of WordSizes.
+	WordSize int
+	// Unsigned makes the integers of the Programmer backend unsigned.
+	Unsigned bool
+}
+
+// Result is the value of an evaluated expression.
+type Result interface {
+	// String formats the value for display.
+	String() string
+	// Float64 returns the value, or the closest float64 to it.
+	Float64() float64
+}
+
+// FloatValue is the Result of the Float backend.
+type FloatValue float64
+
+func (v FloatValue) String() string {
+	return fmt.Sprintf("%g", float64(v))
+}
+func (v FloatValue) Float64() float64 {
+	return float64(v)
+}
+
+// Run evaluates expr against env with the backend selected by opts.
+// A result converted to another base, as in "255 to hex", is a RadixValue.
+func Run(expr parser.Expr, env *parser.Env, opts Options) (Result, error) {
+	result, err := evaluate(expr, env, opts)
+	if base := radixOf(expr); err == nil && base != 0 {
+		return RadixValue{Value: result, Base: base}, nil
+	}
+	return result, err
+}
+
+// evaluate evaluates expr against env with the backend selected by opts.
+func evaluate(expr parser.Expr, env *parser.Env, opts Options) (Result, error) {
+	switch opts.Backend {
+	case Big:
+		return run(expr, NewBigArithmetic(opts.Precision, env), env)
+	case Rational:
+		return run(expr, NewRatArithmetic(opts.Precision, env), env)
+	case Complex:
+		return run(expr, NewComplexArithmetic(env), env)
+	case Decimal:
+		return run(expr, NewDecimalArithmetic(opts.Scale, opts.Rounding, env), env)
+	case Interval:
+		return run(expr, NewIntervalArithmetic(env), env)
+	case Uncertain:
+		return run(expr, NewUncertainArithmetic(env), env)
+	case Programmer:
+		return run(expr, NewIntegerArithmetic(opts.WordSize, opts.Unsigned, env), env)
+	default:
+		if usesCalendar(expr, env) {
+			return run(expr, NewCalendarArithmetic(env), env)
+		}
+		if usesUnits(expr, env) {
+			return run(expr, NewQuantityArithmetic(env), env)
+		}
+		value, err := expr.Eval(env)
+		if err != nil {
+			return nil, err
+		}
+		return FloatValue(value), nil
+	}
+}
+
+func run[T Result](expr parser.Expr, arith Arithmetic[T], env *parser.Env) (Result, error) {
+	value, err := Evaluate(expr, arith, env)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Arithmetic implements the operations needed to evaluate an expression tree
+// over values of type T. Operators are identified by their lexer.TokenKind.
+type Arithmetic[T Result] interface {
+	// Literal converts a number literal.
+	Literal(n parser.NumberExpr) (T, error)
+	// FromFloat converts a float64, such as a constant or a variable assigned
+	// by the Float backend.
+	FromFloat(x float64) (T, error)
+	// Binary applies an infix operator.
+	Binary(op lexer.TokenKind, a, b T) (T, error)
+	// Unary applies a prefix operator.
+	Unary(op lexer.TokenKind, a T) (T, error)
+	// Postfix applies a postfix operator.
+	Postfix(op lexer.TokenKind, a T) (T, error)
+	// Call calls the built-in function name.
+	Call(name string, args []T) (T, error)
+}
+
+// ConstantArithmetic is implemented by an Arithmetic that knows some constants
+// more precisely than their float64 value in parser.Constant.
+type ConstantArithmetic[T Result] interface {
+	// Constant returns the value of the constant name and whether it is known.
+	Constant(name string) (T, bool)
+}
+
+// ComplexArithmetic is implemented by an Arithmetic whose values include the
+// complex numbers.
+type ComplexArithmetic[T Result] interface {
+	// ImaginaryUnit returns the square root of -1.
+	ImaginaryUnit() T
+}
+
+// IntervalArithmetic is implemented by an Arithmetic whose values are ranges
+// or distributions rather than single numbers.
+type IntervalArithmetic[T Result] interface {
+	// PlusMinus returns value with a tolerance, as in "10 ± 0.2".
+	PlusMinus(value, tolerance T) (T, error)
+	// Between returns the values from lo to hi, as in "[9.8, 10.2]".
+	Between(lo, hi T) (T, error)
+}
+
+// UnitArithmetic is implemented by an Arithmetic whose values carry units.
+type UnitArithmetic[T Result] interface {
+	// Unit returns one of the unit called name and whether it exists.
+	Unit(name string) (T, bool)
+	// Quantity returns value measured in unit, as in "5 km/h".
+	Quantity(value T, unit string) (T, error)
+	// Convert returns value expressed in unit, as in "5 km/h to mph".
+	Convert(value T, unit string) (T, error)
+}
+
+// CalendarArithmetic is implemented by an Arithmetic whose values include
+// dates and durations.
+type CalendarArithmetic[T Result] interface {
+	// Date converts a date literal, as in "2026-03-01".
+	Date(n parser.DateExpr) T
+	// Duration converts a duration literal, as in "3h 45m".
+	Duration(n parser.DurationExpr) T
+	// Moment returns the date called name, such as "today", and whether it
+	// exists.
+	Moment(name string) (T, bool)
+}
+
+// Error is an evaluation error located in the source.
+type Error struct {
+	// Span is the range of the source of the expression that failed.
+	Span lexer.Span
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v at offset %d", e.Err, e.Span.Start)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Evaluate walks expr computing its value with arith.
+// Variables are read from and assigned to env. Values assigned here are kept
+// in their exact type T and approximated as float64 for the other backends.
+func Evaluate[T Result](expr parser.Expr, arith Arithmetic[T], env *parser.Env) (T, error) {
+	var zero T
+
+	switch n := expr.(type) {
+	case parser.NumberExpr:
+		value, err := arith.Literal(n)
+		return located(n, value, err)
+	case parser.ImaginaryExpr:
+		if c, ok := arith.(ComplexArithmetic[T]); ok {
+			return c.ImaginaryUnit(), nil
+		}
+		return zero, &parser.ComplexNumberError{Span: n.Span}
+	case parser.IntervalExpr:
+		return interval(n, arith, env)
+	case parser.QuantityExpr:
+		measured, ok := arith.(UnitArithmetic[T])
+		if !ok {
+			return zero, &parser.UnitError{Span: n.Span}
+		}
+		amount, err := Evaluate(n.Value, arith, env)
+		if err != nil {
+			return zero, err
+		}
+		value, err := measured.Quantity(amount, n.Unit)
+		return located(n, value, err)
+	case parser.ConversionExpr:
+		measured, ok := arith.(UnitArithmetic[T])
+		if !ok {
+			return zero, &parser.UnitError{Span: n.Span}
+		}
+		amount, err := Evaluate(n.Value, arith, env)
+		if err != nil {
+			return zero, err
+		}
+		value, err := measured.Convert(amount, n.Unit)
+		return located(n, value, err)
+	case parser.DateExpr:
+		if calendar, ok := arith.(CalendarArithmetic[T]); ok {
+			return calendar.Date(n), nil
+		}
+		return zero, &parser.CalendarError{Span: n.Span}
+	case parser.DurationExpr:
+		if calendar, ok := arith.(CalendarArithmetic[T]); ok {
+			return calendar.Duration(n), nil
+		}
+		return zero, &parser.CalendarError{Span: n.Span}
+	case parser.RadixExpr:
+		value, err := Evaluate(n.Value, arith, env)
+		if err != nil {
+			return zero, err
+		}
+		if _, ok := FormatRadix(value, n.Base); !ok {
+			return zero, &Error{Span: n.Span, Err: errNotRadix}
+		}
+		return value, nil
+	case parser.IdentifierExpr:
+		return lookup(n, arith, env)
+	case parser.AssignmentExpr:
+		value, err := Evaluate(n.Value, arith, env)
+		if err != nil {
+			return zero, err
+		}
+		env.SetExact(n.Target.Name, value.Float64(), value)
+		return value, nil
+	case parser.UnaryExpr:
+		member, err := Evaluate(n.Member, arith, env)
+		if err != nil {
+			return zero, err
+		}
+		value, err := arith.Unary(n.Operator.Kind, member)
+		return located(n, value, err)
+	case parser.PostfixExpr:
+		member, err := Evaluate(n.Member, arith, env)
+		if err != nil {
+			return zero, err
+		}
+		value, err := arith.Postfix(n.Operator.Kind, member)
+		return located(n, value, err)
+	case parser.BinaryExpr:
+		return binary(n, arith, env)
+	case parser.CallExpr:
+		args := make([]T, len(n.Args))
+		for i, arg := range n.Args {
+			value, err := Evaluate(arg, arith, env)
+			if err != nil {
+				return zero, err
+			}
+			args[i] = value
+		}
+		value, err := arith.Call(n.Name, args)
+		return located(n, value, err)
+	default:
+		return zero, &Error{Span: expr.Position(), Err: fmt.Errorf("%T cannot be evaluated", expr)}
+	}
+}
+
+func binary[T Result](n parser.BinaryExpr, arith Arithmetic[T], env *parser.Env) (T, error) {
+	var zero T
+
+	a, err := Evaluate(n.Left, arith, env)
+	if err != nil {
+		return zero, err
+	}
+	b, err := Evaluate(n.Right, arith, env)
+	if err != nil {
+		return zero, err
+	}
+
+	if n.Operator.Kind == lexer.PLUS_MINUS {
+		tolerant, ok := arith.(IntervalArithmetic[T])
+		if !ok {
+			return zero, &parser.IntervalError{Span: n.Span}
+		}
+		value, err := tolerant.PlusMinus(a, b)
+		return located(n, value, err)
+	}
+
+	// Same rule as parser.BinaryExpr: "200 + 10%" adds 10% of 200.
+	if parser.IsPercent(n.Right) && (n.Operator.Kind == lexer.PLUS || n.Operator.Kind == lexer.DASH) {
+		if b, err = arith.Binary(lexer.STAR, a, b); err != nil {
+			return zero, &Error{Span: n.Span, Err: err}
+		}
+	}
+
+	value, err := arith.Binary(n.Operator.Kind, a, b)
+	return located(n, value, err)
+}
+
+// interval evaluates the bounds of an IntervalExpr and combines them.
+func interval[T Result](n parser.IntervalExpr, arith Arithmetic[T], env *parser.Env) (T, error) {
+	var zero T
+
+	bounded, ok := arith.(IntervalArithmetic[T])
+	if !ok {
+		return zero, &parser.IntervalError{Span: n.Span}
+	}
+	lo, err := Evaluate(n.Lo, arith, env)
+	if err != nil {
+		return zero, err
+	}
+	hi, err := Evaluate(n.Hi, arith, env)
+	if err != nil {
+		return zero, err
+	}
+
+	value, err := bounded.Between(lo, hi)
+	return located(n, value, err)
+}
+
+// lookup resolves an identifier: an exact value assigned by this backend first,
+// then the float64 value of a variable, then a constant, then a date such as
+// "today", then a unit.
+func lookup[T Result](n parser.IdentifierExpr, arith Arithmetic[T], env *parser.Env) (T, error) {
+	if exact, exists := env.GetExact(n.Name); exists {
+		if value, sameType := exact.(T); sameType {
+			return value, nil
+		}
+	}
+	if approx, exists := env.Get(n.Name); exists {
+		value, err := arith.FromFloat(approx)
+		return located(n, value, err)
+	}
+	if constant, exists := parser.LookupConstant(n.Name); exists {
+		if precise, ok := arith.(ConstantArithmetic[T]); ok {
+			if value, known := precise.Constant(constant.Name); known {
+				return value, nil
+			}
+		}
+		value, err := arith.FromFloat(constant.Value)
+		return located(n, value, err)
+	}
+	if calendar, ok := arith.(CalendarArithmetic[T]); ok {
+		if value, known := calendar.Moment(n.Name); known {
+			return value, nil
+		}
+	}
+	if measured, ok := arith.(UnitArithmetic[T]); ok {
+		if value, known := measured.Unit(n.Name); known {
+			return value, nil
+		}
+	}
+
+	var zero T
+	return zero, &parser.UndefinedVariableError{Name: n.Name, Span: n.Span}
+}
+
+// anyNode reports whether match holds for expr or any expression within it.
+func anyNode(expr parser.Expr, match func(parser.Expr) bool) bool {
+	if match(expr) {
+		return true
+	}
+	switch n := expr.(type) {
+	case parser.QuantityExpr:
+		return anyNode(n.Value, match)
+	case parser.ConversionExpr:
+		return anyNode(n.Value, match)
+	case parser.RadixExpr:
+		return anyNode(n.Value, match)
+	case parser.AssignmentExpr:
+		return anyNode(n.Value, match)
+	case parser.UnaryExpr:
+		return anyNode(n.Member, match)
+	case parser.PostfixExpr:
+		return anyNode(n.Member, match)
+	case parser.BinaryExpr:
+		return anyNode(n.Left, match) || anyNode(n.Right, match)
+	case parser.IntervalExpr:
+		return anyNode(n.Lo, match) || anyNode(n.Hi, match)
+	case parser.CallExpr:
+		for _, arg := range n.Args {
+			if anyNode(arg, match) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// located attaches the source range of expr to an error from an Arithmetic.
+func located[T any](expr parser.Expr, value T, err error) (T, error) {
+	if err != nil {
+		return value, &Error{Span: expr.Position(), Err: err}
+	}
+	return value, nil
+}
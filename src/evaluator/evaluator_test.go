@@ -0,0 +1,1186 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package evaluator_test
+
+import (
+	"calculator/src/evaluator"
+	"calculator/src/lexer"
+	"calculator/src/parser"
+	"calculator/src/units"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+type EquationResult struct {
+	eq             string
+	expectedResult string
+}
+
+func run(t *testing.T, eq string, env *parser.Env, opts evaluator.Options) (evaluator.Result, error) {
+	t.Helper()
+	ast, err := parser.Parse(eq)
+	if err != nil {
+		t.Fatalf("In Equation %s\n Unexpected error: %v", eq, err)
+	}
+	return evaluator.Run(ast, env, opts)
+}
+
+var bigEquations = []EquationResult{
+	// Integers stay exact
+	{"2 ^ 100", "1267650600228229401496703205376"},
+	{"25!", "15511210043330985984000000"},
+	{"2 ^ 64 - 1", "18446744073709551615"},
+	{"12345678901234567890 * 98765432109876543210", "1219326311370217952237463801111263526900"},
+	{"2 ^ 64 / 2 ^ 32", "4294967296"},
+	{"0xFFFF_FFFF_FFFF_FFFF + 1", "18446744073709551616"},
+	{"0x1_0000_0000_0000_0000", "18446744073709551616"},
+	{"1e400 / 1e399", "10"},
+	{"7 % 4", "-1"},
+	{"sqrt(2 ^ 80)", "1099511627776"},
+	{"3 ^ 4611686018427387904", "+Inf"},
+	{"200 + 10%", "220"},
+	{"2 ^ 70 | 1", "1180591620717411303425"},
+	{"1 << 100 >> 99", "2"},
+	{"~0 xor 5", "-6"},
+	{"-8 >> 1", "-4"},
+
+	// Decimals are not binary fractions
+	{"0.1 + 0.2", "0.3"},
+	{"1 / 4", "0.25"},
+	{"1.1 * 1.1", "1.21"},
+
+	// Results are shown with the configured precision
+	{"1 / 3", "0.33333333333333333333333333333333333333333333333333"},
+	{"sqrt(2)", "1.4142135623730950488016887242096980785696718753769"},
+	{"pi", "3.1415926535897932384626433832795028841971693993751"},
+	{"2 r2", "1.4142135623730950488016887242096980785696718753769"},
+	{"2 ^ 0.5", "1.4142135623730950488016887242096980785696718753769"},
+	{"10 ^ 0.25", "1.7782794100389228012254211951926848447357905264023"},
+	{"2 ^ -0.5", "0.70710678118654752440084436210484903928483593768847"},
+	{"2 r3", "1.2599210498948731647672106072782283505702514647015"},
+	{"1.0001 ^ 100000.5", "22016.556793836682074954672069929210598166480742807"},
+
+	// Rounding and comparison
+	{"round(2.5)", "3"},
+	{"round(-2.5)", "-3"},
+	{"floor(-1.5)", "-2"},
+	{"ceil(1.2)", "2"},
+	{"round(1 / 3, 3)", "0.333"},
+	{"max(1, 2 ^ 70, 3)", "1180591620717411303424"},
+	{"abs(-0.5)", "0.5"},
+}
+
+func TestBigBackend(t *testing.T) {
+	opts := evaluator.Options{Backend: evaluator.Big, Precision: evaluator.DefaultPrecision}
+	for _, eq := range bigEquations {
+		result, err := run(t, eq.eq, parser.NewEnv(), opts)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+			continue
+		}
+		if result.String() != eq.expectedResult {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+		}
+	}
+}
+
+func TestBigBackendErrors(t *testing.T) {
+	opts := evaluator.Options{Backend: evaluator.Big}
+	for _, eq := range []string{"0 / 0", "sqrt(-1)", "1 << 9223372036854775807", "1 / 0", "1.5 / 0", "7 % 0", "0 ^ -1"} {
+		_, err := run(t, eq, parser.NewEnv(), opts)
+		var evalErr *evaluator.Error
+		if !errors.As(err, &evalErr) {
+			t.Errorf("In Equation %s\n Expected an evaluation error but had %v", eq, err)
+		}
+	}
+}
+
+var ratEquations = []EquationResult{
+	// Fractions stay exact
+	{"1/3 + 1/6", "1/2"},
+	{"2/4", "1/2"},
+	{"0.1 + 0.2", "3/10"},
+	{"1.5 * 4", "6"},
+	{"(2/3) ^ 3", "8/27"},
+	{"(2/3) ^ -2", "9/4"},
+	{"1e400 / 1e399", "10"},
+	{"-3/4", "-3/4"},
+	{"50%", "1/2"},
+	{"200 + 10%", "220"},
+	{"7/2 % 1", "-1/2"},
+	{"sqrt(9/16)", "3/4"},
+	{"(1/4) r2", "1/2"},
+	{"round(7/3, 2)", "233/100"},
+	{"floor(-7/2)", "-4"},
+	{"max(1/3, 1/2)", "1/2"},
+	{"10!/8!", "90"},
+
+	// Irrational operations fall back to float
+	{"sqrt(2)", "1.4142135623730951"},
+	{"pi / 2", "1.5707963267949"},
+	{"2 ^ 0.5", "1.4142135623731"},
+	{"3 ^ 4611686018427387904", "+Inf"},
+}
+
+func TestRationalBackend(t *testing.T) {
+	opts := evaluator.Options{Backend: evaluator.Rational}
+	for _, eq := range ratEquations {
+		result, err := run(t, eq.eq, parser.NewEnv(), opts)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+			continue
+		}
+		if result.String() != eq.expectedResult {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+		}
+	}
+
+	_, err := run(t, "1 / (1/2 - 0.5)", parser.NewEnv(), opts)
+	if err == nil {
+		t.Errorf("Expected a division by zero error")
+	}
+}
+
+func TestFractionFormats(t *testing.T) {
+	opts := evaluator.Options{Backend: evaluator.Rational, Precision: 10}
+	formats := []struct {
+		eq                     string
+		fraction, mixed, float string
+	}{
+		{"3/2", "3/2", "1 1/2", "1.5"},
+		{"-7/3", "-7/3", "-2 1/3", "-2.333333333"},
+		{"1/8", "1/8", "1/8", "0.125"},
+		{"4/2", "2", "2", "2"},
+		{"sqrt(2)", "1.4142135623730951", "1.4142135623730951", "1.4142135623730951"},
+	}
+
+	for _, f := range formats {
+		result, err := run(t, f.eq, parser.NewEnv(), opts)
+		if err != nil {
+			t.Fatalf("In Equation %s\n Unexpected evaluation error: %v", f.eq, err)
+		}
+		value := result.(evaluator.RatValue)
+		for format, expected := range map[evaluator.FractionFormat]string{
+			evaluator.Fraction:        f.fraction,
+			evaluator.Mixed:           f.mixed,
+			evaluator.DecimalNotation: f.float,
+		} {
+			if got := value.Format(format); got != expected {
+				t.Errorf("In Equation %s\n Expected %s in %v format but had %s", f.eq, expected, format, got)
+			}
+		}
+	}
+}
+
+var complexEquations = []EquationResult{
+	// Roots and logarithms of negative numbers
+	{"(-1) ^ 0.5", "i"},
+	{"sqrt(-4)", "2i"},
+	{"(-4) r2", "2i"},
+	{"ln(-1)", "3.141592653589793i"},
+
+	// The imaginary unit
+	{"i * i", "-1"},
+	{"(3 + 4i) * (3 - 4i)", "25"},
+	{"(3 + 4i) ^ 2", "-7+24i"},
+	{"(1 + 2i) / (3 - 4i)", "-0.2+0.4i"},
+	{"-2i", "-2i"},
+	{"1 - i", "1-i"},
+	{"2i / 4", "0.5i"},
+
+	// Complex functions
+	{"abs(3 + 4i)", "5"},
+	{"re(3 + 4i)", "3"},
+	{"im(3 + 4i)", "4"},
+	{"conj(3 + 4i)", "3-4i"},
+	{"arg(2i)", "1.5707963267948966"},
+	{"exp(i * pi)", "-1"},
+
+	// Real operations behave like the Float backend
+	{"2 + 3 * 4", "14"},
+	{"sin(0)", "0"},
+	{"5!", "120"},
+}
+
+func TestComplexBackend(t *testing.T) {
+	opts := evaluator.Options{Backend: evaluator.Complex}
+	for _, eq := range complexEquations {
+		result, err := run(t, eq.eq, parser.NewEnv(), opts)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+			continue
+		}
+		if result.String() != eq.expectedResult {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+		}
+	}
+
+	for _, eq := range []string{"(1 + i)!", "max(i, 1)", "(2i) % 2"} {
+		if _, err := run(t, eq, parser.NewEnv(), opts); err == nil {
+			t.Errorf("In Equation %s\n Expected an error for a complex operand", eq)
+		}
+	}
+}
+
+func TestComplexFormats(t *testing.T) {
+	env := parser.NewEnv()
+	env.Angle = parser.Degrees
+	formats := []struct {
+		eq                 string
+		rectangular, polar string
+	}{
+		{"3 + 4i", "3+4i", "5∠53.13°"},
+		{"-2", "-2", "2∠180°"},
+		{"-i", "-i", "1∠-90°"},
+		{"(1 + i) * 2", "2+2i", "2.8284271247461903∠45°"},
+	}
+
+	for _, f := range formats {
+		result, err := run(t, f.eq, env, evaluator.Options{Backend: evaluator.Complex})
+		if err != nil {
+			t.Fatalf("In Equation %s\n Unexpected evaluation error: %v", f.eq, err)
+		}
+		value := result.(evaluator.ComplexValue)
+		if got := value.Format(evaluator.Rectangular); got != f.rectangular {
+			t.Errorf("In Equation %s\n Expected %s but had %s", f.eq, f.rectangular, got)
+		}
+		if got := value.Format(evaluator.Polar); got != f.polar {
+			t.Errorf("In Equation %s\n Expected %s but had %s", f.eq, f.polar, got)
+		}
+	}
+}
+
+func TestImaginaryUnitNeedsComplex(t *testing.T) {
+	for _, backend := range []evaluator.Backend{evaluator.Float, evaluator.Big, evaluator.Rational} {
+		_, err := run(t, "2 + 3i", parser.NewEnv(), evaluator.Options{Backend: backend})
+		var complexErr *parser.ComplexNumberError
+		if !errors.As(err, &complexErr) || complexErr.Span.Start != 5 {
+			t.Errorf("Expected a complex number error at offset 5 with %v but had %v", backend, err)
+		}
+	}
+}
+
+var decimalEquations = []EquationResult{
+	// Decimal amounts are exact
+	{"0.1 + 0.2", "0.30"},
+	{"1.1 * 3", "3.30"},
+	{"19.99 * 3 - 0.03", "59.94"},
+	{"1e6 + 0.01", "1000000.01"},
+	{"-0.5", "-0.50"},
+	{"2 ^ -2", "0.25"},
+	{"1.5 ^ 2", "2.25"},
+	{"200 + 7.5%", "215.00"},
+	{"7 % 4", "-1.00"},
+
+	// Quotients keep enough places to round correctly
+	{"1 / 3", "0.33"},
+	{"1 / 3 * 3", "1.00"},
+	{"100 / 7", "14.29"},
+
+	// Display rounds half to even by default
+	{"0.125", "0.12"},
+	{"0.135", "0.14"},
+	{"-0.125", "-0.12"},
+	{"round(2.5)", "2.00"},
+	{"round(1.2345, 1)", "1.20"},
+	{"floor(-1.5)", "-2.00"},
+	{"ceil(1.01)", "2.00"},
+	{"max(0.1, 0.12)", "0.12"},
+
+	// Exponents too large to keep exact are approximated
+	{"1e-100000000", "0.00"},
+	{"1e-100000000 + 1", "1.00"},
+	{"1e-200000 * 1e-200000", "0.00"},
+	{"0.5 ^ 4611686018427387904", "0.00"},
+}
+
+func TestDecimalBackend(t *testing.T) {
+	opts := evaluator.Options{Backend: evaluator.Decimal, Scale: 2}
+	for _, eq := range decimalEquations {
+		result, err := run(t, eq.eq, parser.NewEnv(), opts)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+			continue
+		}
+		if result.String() != eq.expectedResult {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+		}
+	}
+
+	if _, err := run(t, "1 / (0.1 - 0.1)", parser.NewEnv(), opts); err == nil {
+		t.Errorf("Expected a division by zero error")
+	}
+	if _, err := run(t, "3 ^ 4611686018427387904", parser.NewEnv(), opts); err == nil {
+		t.Errorf("Expected an overflowing power to be an error")
+	}
+}
+
+func TestDecimalRoundingModes(t *testing.T) {
+	cases := []struct {
+		eq                     string
+		scale                  int
+		halfEven, halfUp, down string
+	}{
+		{"0.125", 2, "0.12", "0.13", "0.12"},
+		{"-0.125", 2, "-0.12", "-0.13", "-0.12"},
+		{"2 / 3", 2, "0.67", "0.67", "0.66"},
+		{"2.5", 0, "2", "3", "2"},
+		{"round(0.125, 2) * 10", 3, "1.200", "1.300", "1.200"},
+		{"1.005 * 1000", 1, "1005.0", "1005.0", "1005.0"},
+	}
+
+	for _, c := range cases {
+		for mode, expected := range map[evaluator.RoundingMode]string{
+			evaluator.HalfEven: c.halfEven,
+			evaluator.HalfUp:   c.halfUp,
+			evaluator.Down:     c.down,
+		} {
+			opts := evaluator.Options{Backend: evaluator.Decimal, Scale: c.scale, Rounding: mode}
+			result, err := run(t, c.eq, parser.NewEnv(), opts)
+			if err != nil {
+				t.Fatalf("In Equation %s\n Unexpected evaluation error: %v", c.eq, err)
+			}
+			if result.String() != expected {
+				t.Errorf("In Equation %s\n Expected %s rounding %v but had %s", c.eq, expected, mode, result.String())
+			}
+		}
+	}
+}
+
+var intervalEquations = []EquationResult{
+	// Literal syntax, with bounds that are not binary fractions widened
+	{"10 ± 0.2", "[9.79999999999999, 10.2000000000001] = 10 ± 0.2"},
+	{"[9.8, 10.2]", "[9.79999999999999, 10.2000000000001] = 10 ± 0.2"},
+	{"[9.5, 10.5]", "[9.5, 10.5] = 10 ± 0.5"},
+	{"2 * 10 ± 0.5", "[19, 21] = 20 ± 1"},
+	{"[1, 2] + [3, 4]", "[4, 6] = 5 ± 1"},
+	{"[1, 2] - [3, 4]", "[-3, -1] = -2 ± 1"},
+	{"[-1, 2] * [3, 4]", "[-4, 8] = 2 ± 6"},
+	{"[-2, 1] ^ 2", "[0, 4] = 2 ± 2"},
+	{"-[1, 2]", "[-2, -1] = -1.5 ± 0.5"},
+	{"abs([-3, 1])", "[0, 3] = 1.5 ± 1.5"},
+	{"sqrt([4, 9])", "[2, 3] = 2.5 ± 0.5"},
+	{"max([1, 5], [2, 3])", "[2, 5] = 3.5 ± 1.5"},
+
+	// Division by intervals containing zero
+	{"[1, 2] / [2, 4]", "[0.25, 1] = 0.625 ± 0.375"},
+	{"1 / [0, 2]", "[0.5, +Inf] = +Inf ± +Inf"},
+	{"-1 / [0, 2]", "[-Inf, -0.5] = -Inf ± +Inf"},
+	{"1 / [-1, 2]", "[-Inf, +Inf] = 0 ± +Inf"},
+	{"[-1, 1] / [1, 2]", "[-1, 1] = 0 ± 1"},
+
+	// Plain numbers are single points
+	{"2 + 3 * 4", "[14, 14] = 14 ± 0"},
+
+	// Bounds are written rounded outwards
+	{"1 / 3", "[0.333333333333333, 0.333333333333334] = 0.333333333333 ± 5.55111512313e-17"},
+	{"0.1 + 0.2", "[0.299999999999999, 0.300000000000001] = 0.3 ± 5.55111512313e-17"},
+}
+
+func TestIntervalBackend(t *testing.T) {
+	opts := evaluator.Options{Backend: evaluator.Interval}
+	for _, eq := range intervalEquations {
+		result, err := run(t, eq.eq, parser.NewEnv(), opts)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+			continue
+		}
+		if result.String() != eq.expectedResult {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+		}
+	}
+
+	for _, eq := range []string{"1 / [0, 0]", "[2, 1]", "sqrt([-2, -1])"} {
+		if _, err := run(t, eq, parser.NewEnv(), opts); err == nil {
+			t.Errorf("In Equation %s\n Expected an error", eq)
+		}
+	}
+}
+
+func TestIntervalEnclosure(t *testing.T) {
+	env := parser.NewEnv()
+	env.Angle = parser.Degrees
+	cases := []struct {
+		eq    string
+		exact float64
+		width float64
+	}{
+		{"0.1 + 0.2", 0.3, 1e-15},
+		{"1 / 3", 1.0 / 3, 1e-15},
+		{"0.1 * 3", 0.3, 1e-15},
+		{"sqrt(2)", math.Sqrt2, 1e-15},
+		{"pi", math.Pi, 1e-15},
+		{"sin([0, 180])", 1, 1.1},
+		{"cos([80, 100])", 0, 0.4},
+	}
+
+	for _, c := range cases {
+		result, err := run(t, c.eq, env, evaluator.Options{Backend: evaluator.Interval})
+		if err != nil {
+			t.Fatalf("In Equation %s\n Unexpected evaluation error: %v", c.eq, err)
+		}
+		value := result.(evaluator.IntervalValue)
+		if !value.Contains(c.exact) || value.Hi-value.Lo > c.width {
+			t.Errorf("In Equation %s\n Expected an interval narrower than %g around %g but had %v", c.eq, c.width, c.exact, value)
+		}
+	}
+}
+
+func TestIntervalDisplayedEnclosure(t *testing.T) {
+	cases := []struct {
+		eq    string
+		exact *big.Rat
+	}{
+		{"1 / 3", big.NewRat(1, 3)},
+		{"2 / 3", big.NewRat(2, 3)},
+		{"0.1 + 0.2", big.NewRat(3, 10)},
+		{"0.1 * 3", big.NewRat(3, 10)},
+		{"-1 / 7", big.NewRat(-1, 7)},
+		{"10 ± 0.2", big.NewRat(49, 5)},
+		{"10 ± 0.2", big.NewRat(51, 5)},
+		{"[9.8, 10.2] * 2", big.NewRat(98, 5)},
+	}
+
+	for _, c := range cases {
+		result, err := run(t, c.eq, parser.NewEnv(), evaluator.Options{Backend: evaluator.Interval})
+		if err != nil {
+			t.Fatalf("In Equation %s\n Unexpected evaluation error: %v", c.eq, err)
+		}
+		text := result.String()
+		lo, rest, _ := strings.Cut(strings.TrimPrefix(text, "["), ", ")
+		hi, _, _ := strings.Cut(rest, "]")
+		loRat, okLo := new(big.Rat).SetString(lo)
+		hiRat, okHi := new(big.Rat).SetString(hi)
+		if !okLo || !okHi || loRat.Cmp(c.exact) > 0 || hiRat.Cmp(c.exact) < 0 {
+			t.Errorf("In Equation %s\n Expected the bounds shown in %s to contain %s", c.eq, text, c.exact.RatString())
+		}
+	}
+}
+
+func TestIntervalNeedsBackend(t *testing.T) {
+	for _, eq := range []string{"10 ± 0.2", "2 * [1, 2]"} {
+		_, err := run(t, eq, parser.NewEnv(), evaluator.Options{Backend: evaluator.Float})
+		var intervalErr *parser.IntervalError
+		if !errors.As(err, &intervalErr) {
+			t.Errorf("In Equation %s\n Expected an interval error but had %v", eq, err)
+		}
+	}
+}
+
+var uncertainEquations = []EquationResult{
+	{"(12.3 ± 0.1) * (4.5 ± 0.05)", "55.35 ± 0.76"},
+	{"10 ± 0.2", "10.00 ± 0.20"},
+	{"(10 ± 0.2) + (5 ± 0.1)", "15.00 ± 0.22"},
+	{"2 * 3 ± 0.1", "6.00 ± 0.20"},
+	{"-(3 ± 0.1)", "-3.00 ± 0.10"},
+	{"(2 ± 0.1) ^ 2", "4.00 ± 0.40"},
+	{"2 ^ (3 ± 0.1)", "8.00 ± 0.55"},
+	{"sqrt(16 ± 0.8)", "4.00 ± 0.10"},
+	{"ln(10 ± 0.1)", "2.303 ± 0.010"},
+	{"max(1 ± 0.1, 2 ± 0.3)", "2.00 ± 0.30"},
+	{"200 + (10 ± 1)%", "220.0 ± 2.0"},
+
+	// A range is a uniform distribution
+	{"[9, 11]", "10.00 ± 0.58"},
+
+	// Significant figures
+	{"12345 ± 678", "12350 ± 680"},
+	{"6.02214 ± 0.00012 * 10^23", "(6.02214 ± 0.00012)e23"},
+	{"1.5e-9 ± 2e-11", "(1.500 ± 0.020)e-9"},
+	{"1 ± 0.0996", "1.000 ± 0.100"},
+	{"2 + 3", "5"},
+}
+
+func TestUncertainBackend(t *testing.T) {
+	opts := evaluator.Options{Backend: evaluator.Uncertain}
+	for _, eq := range uncertainEquations {
+		result, err := run(t, eq.eq, parser.NewEnv(), opts)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+			continue
+		}
+		if result.String() != eq.expectedResult {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+		}
+	}
+
+	for _, eq := range []string{"1 / (0 ± 1)", "sqrt(0 ± 1)", "[2, 1]", "sqrt(-1 ± 0.1)"} {
+		if _, err := run(t, eq, parser.NewEnv(), opts); err == nil {
+			t.Errorf("In Equation %s\n Expected an error", eq)
+		}
+	}
+}
+
+func TestUncertainCorrelation(t *testing.T) {
+	env := parser.NewEnv()
+	opts := evaluator.Options{Backend: evaluator.Uncertain}
+	session := []EquationResult{
+		{"x = 10 ± 0.2", "10.00 ± 0.20"},
+		{"x - x", "0"},
+		{"x / x", "1"},
+		{"x * x", "100.0 ± 4.0"},
+		{"y = 10 ± 0.2", "10.00 ± 0.20"},
+		{"x * y", "100.0 ± 2.8"},
+		{"x - y", "0.00 ± 0.28"},
+		{"z = x + 1", "11.00 ± 0.20"},
+		{"z - x", "1"},
+	}
+
+	for _, eq := range session {
+		result, err := run(t, eq.eq, env, opts)
+		if err != nil {
+			t.Fatalf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+		}
+		if result.String() != eq.expectedResult {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+		}
+	}
+}
+
+var quantityEquations = []EquationResult{
+	// Units combine through products and quotients
+	{"3 m * 2 s", "6 m*s"},
+	{"5 km / 2 h", "2.5 km/h"},
+	{"(2 m)^2", "4 m^2"},
+	{"sqrt(9 m^2)", "3 m"},
+	{"9.81 m/s^2 * 70 kg", "686.7 m*kg/s^2"},
+	{"5 km / 2 m", "2500"},
+	{"1 h / 1 min", "60"},
+
+	// Sums convert to the unit on the left
+	{"1 m + 20 cm", "1.2 m"},
+	{"200 m + 10%", "220 m"},
+	{"max(1 m, 50 cm)", "1 m"},
+	{"-(3 ft)", "-3 ft"},
+
+	// Conversions
+	{"5 km / 2 h to mph", "1.55342798059333 mph"},
+	{"60 mph to km/h", "96.56064 km/h"},
+	{"12 in to ft", "1 ft"},
+	{"1 lb to kg", "0.45359237 kg"},
+	{"9.81 m/s^2 * 70 kg to N", "686.7 N"},
+	{"1 kWh to J", "3.6e+06 J"},
+	{"1 acre to m^2", "4046.8564224 m^2"},
+	{"1 km + 500 m to mi", "0.932056788356001 mi"},
+	{"1 eV to J", "1.602176634e-19 J"},
+
+	// Unit names and constants
+	{"c * 2 s to km", "599584.916 km"},
+	{"c * 1 s", "2.99792458e+08 m"},
+	{"60 km/h * 90 min", "90 km"},
+	{"2 h to min", "120 min"},
+	{"km to m", "1000 m"},
+}
+
+func TestQuantities(t *testing.T) {
+	opts := evaluator.Options{Backend: evaluator.Float}
+	for _, eq := range quantityEquations {
+		result, err := run(t, eq.eq, parser.NewEnv(), opts)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+			continue
+		}
+		if result.String() != eq.expectedResult {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+		}
+	}
+}
+
+func TestQuantityErrors(t *testing.T) {
+	cases := []struct {
+		eq, message string
+	}{
+		{"1 m + 1 s", "incompatible units m and s at offset 0"},
+		{"1 m + 1", "m is incompatible with a number without units at offset 0"},
+		{"5 m to s", "incompatible units m and s at offset 0"},
+		{"3 m to parsec", "unknown unit \"parsec\" at offset 0"},
+		{"sin(3 m)", "sin is not defined for quantities with units at offset 0"},
+		{"2 ^ (3 m)", "an exponent cannot have a unit at offset 0"},
+		{"sqrt(2 m)", "m has no square root at offset 0"},
+		{"(2 m)!", "expected a number without units but had 2 m at offset 0"},
+	}
+
+	for _, c := range cases {
+		_, err := run(t, c.eq, parser.NewEnv(), evaluator.Options{Backend: evaluator.Float})
+		if err == nil || err.Error() != c.message {
+			t.Errorf("In Equation %s\n Expected the error %q but had %v", c.eq, c.message, err)
+		}
+	}
+
+	_, err := run(t, "5 km", parser.NewEnv(), evaluator.Options{Backend: evaluator.Big})
+	var unitErr *parser.UnitError
+	if !errors.As(err, &unitErr) {
+		t.Errorf("Expected a unit error with the Big backend but had %v", err)
+	}
+}
+
+func TestQuantityVariables(t *testing.T) {
+	env := parser.NewEnv()
+	session := []EquationResult{
+		{"d = 3 ft", "3 ft"},
+		{"d * 2", "6 ft"},
+		{"d to m", "0.9144 m"},
+		{"m = 5", "5"},
+		{"m * 2", "10"},
+		{"2 m", "2 m"},
+		{"2 m/s", "2 m/s"},
+		{"2 km", "2 km"},
+		{"speed = 100 km / 2 h", "50 km/h"},
+		{"speed * 30 min", "25 km"},
+	}
+
+	for _, eq := range session {
+		result, err := run(t, eq.eq, env, evaluator.Options{Backend: evaluator.Float})
+		if err != nil {
+			t.Fatalf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+		}
+		if result.String() != eq.expectedResult {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+		}
+	}
+}
+
+func TestVariablesDoNotShadowUnits(t *testing.T) {
+	for _, name := range []string{"s", "m", "g", "A", "T", "F", "K"} {
+		env := parser.NewEnv()
+		session := []EquationResult{
+			{name + " = 4", "4"},
+			{"2 " + name, "2 " + name},
+			{"2 * " + name, "8"},
+			{"2 " + name + "^2", "2 " + name + "^2"},
+		}
+		for _, eq := range session {
+			result, err := run(t, eq.eq, env, evaluator.Options{Backend: evaluator.Float})
+			if err != nil {
+				t.Fatalf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+			}
+			if result.String() != eq.expectedResult {
+				t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+			}
+		}
+	}
+
+	env := parser.NewEnv()
+	for _, eq := range []string{"m = 3", "s = 4"} {
+		if _, err := run(t, eq, env, evaluator.Options{}); err != nil {
+			t.Fatalf("In Equation %s\n Unexpected evaluation error: %v", eq, err)
+		}
+	}
+	if result, err := run(t, "1 km + 500 m to mi", env, evaluator.Options{}); err != nil || result.String() != "0.932056788356001 mi" {
+		t.Errorf("Expected 1 km + 500 m to be 0.932056788356001 mi but had %v, %v", result, err)
+	}
+	if result, err := run(t, "1 m + 1 s", env, evaluator.Options{}); err == nil {
+		t.Errorf("Expected 1 m + 1 s to be a dimension error but had %s", result.String())
+	}
+}
+
+func TestCurrencies(t *testing.T) {
+	rates, err := units.ParseRates([]byte(`{"base": "EUR", "date": "2025-03-14", "rates": {"USD": 1.25, "GBP": 0.8}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	units.SetRates(rates)
+	t.Cleanup(func() { units.SetRates(nil) })
+
+	equations := []EquationResult{
+		{"120 USD", "120 USD"},
+		{"120 USD to EUR", "96 EUR (rates of 2025-03-14)"},
+		{"120 USD to GBP", "76.8 GBP (rates of 2025-03-14)"},
+		{"10 EUR + 5 USD", "14 EUR (rates of 2025-03-14)"},
+		{"10 USD + 5 USD", "15 USD"},
+		{"(10 USD + 5 EUR) * 2", "32.5 USD (rates of 2025-03-14)"},
+		{"1.5 EUR/L * 40 L", "60 EUR"},
+		{"1.5 EUR/L to USD/gal", "7.097647095 USD/gal (rates of 2025-03-14)"},
+		{"10 USD / 4 EUR", "2 (rates of 2025-03-14)"},
+	}
+	for _, eq := range equations {
+		result, err := run(t, eq.eq, parser.NewEnv(), evaluator.Options{Backend: evaluator.Float})
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+			continue
+		}
+		if result.String() != eq.expectedResult {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+		}
+	}
+
+	if _, err := run(t, "5 USD + 1 m", parser.NewEnv(), evaluator.Options{Backend: evaluator.Float}); err == nil {
+		t.Errorf("Expected an error adding USD and m")
+	}
+}
+
+func TestConstantUnits(t *testing.T) {
+	for _, constant := range parser.Constants() {
+		expected, err := units.Parse(constant.Unit)
+		if err != nil {
+			t.Errorf("Constant %s: unexpected error in unit %s: %v", constant.Name, constant.Unit, err)
+			continue
+		}
+		result, err := run(t, constant.Name+" * 1 m / m", parser.NewEnv(), evaluator.Options{Backend: evaluator.Float})
+		if err != nil {
+			t.Errorf("Constant %s: unexpected error: %v", constant.Name, err)
+			continue
+		}
+		if unit := result.(evaluator.QuantityValue).Unit; unit.String() != expected.String() {
+			t.Errorf("Constant %s: expected the unit %s but had %s", constant.Name, expected, unit)
+		}
+	}
+}
+
+var calendarEquations = []EquationResult{
+	// Dates moved by lengths of time
+	{"2026-03-01 + 90 days", "2026-05-30"},
+	{"2026-03-01 - 1 week", "2026-02-22"},
+	{"2026-01-31 + 1 month", "2026-02-28"},
+	{"2026-03-01 + 7973 yr", "9999-03-01"},
+	{"2024-02-29 + 1 year", "2025-02-28"},
+	{"2026-12-31 + 2 months", "2027-02-28"},
+	{"90 days + 2026-03-01", "2026-05-30"},
+	{"2026-03-01 + 36 h", "2026-03-02 12:00"},
+	{"2026-03-01T09:30 + 1d 12h", "2026-03-02 21:30"},
+	{"2026-03-01 09:30 + 30 s", "2026-03-01 09:30:30"},
+
+	// Differences of dates are durations
+	{"2026-12-25 - 2026-10-18", "68d"},
+	{"2026-03-01 - 2026-03-08", "-7d"},
+	{"2026-03-01T18:15 - 2026-03-01T09:30", "8h 45min"},
+	{"(2026-12-25 - 2026-10-18) to week", "9.71428571428571 week"},
+
+	// Durations
+	{"3h 45m * 4", "15h"},
+	{"3h 45m + 15 min", "4h"},
+	{"2 h + 3h 45m", "5h 45min"},
+	{"1d 12h / 2", "18h"},
+	{"3h 45m / 15 min", "15"},
+	{"3h 45m to min", "225 min"},
+	{"-1h 30min", "-1h 30min"},
+	{"1m 0.5s", "1min 0.5s"},
+	{"1h 30m * 60 km/h", "90 km"},
+
+	// Calendar functions
+	{"weekday(2027-01-01)", "Friday"},
+	{"weekday(2026-03-01 + 1 day)", "Monday"},
+	{"workday(2026-03-06, 1)", "2026-03-09"},
+	{"workday(2026-03-02, 10)", "2026-03-16"},
+	{"workdays(2026-03-02, 2026-03-16)", "10"},
+}
+
+func TestCalendar(t *testing.T) {
+	opts := evaluator.Options{Backend: evaluator.Float}
+	for _, eq := range calendarEquations {
+		result, err := run(t, eq.eq, parser.NewEnv(), opts)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+			continue
+		}
+		if result.String() != eq.expectedResult {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+		}
+	}
+}
+
+func TestCalendarToday(t *testing.T) {
+	env := parser.NewEnv()
+	opts := evaluator.Options{Backend: evaluator.Float}
+
+	result, err := run(t, "today", env, opts)
+	if err != nil {
+		t.Fatalf("Unexpected evaluation error: %v", err)
+	}
+	if expected := time.Now().Format(time.DateOnly); result.String() != expected {
+		t.Errorf("Expected today to be %s but had %s", expected, result)
+	}
+
+	christmas := time.Date(time.Now().Year(), time.December, 25, 0, 0, 0, 0, time.UTC)
+	result, err = run(t, christmas.Format(time.DateOnly)+" - today", env, opts)
+	if err != nil {
+		t.Fatalf("Unexpected evaluation error: %v", err)
+	}
+	today, _ := time.Parse(time.DateOnly, time.Now().Format(time.DateOnly))
+	if days := christmas.Sub(today).Hours() / 24; result.Float64() != days*86400 {
+		t.Errorf("Expected %g days to Christmas but had %s", days, result)
+	}
+
+	if _, err := run(t, "now - today", env, opts); err != nil {
+		t.Errorf("Unexpected evaluation error: %v", err)
+	}
+}
+
+func TestCalendarVariables(t *testing.T) {
+	env := parser.NewEnv()
+	session := []EquationResult{
+		{"start = 2026-03-01", "2026-03-01"},
+		{"shift = 3h 45m", "3h 45min"},
+		{"start + 4 * shift", "2026-03-01 15:00"},
+		{"day = weekday(start)", "Sunday"},
+		{"end = workday(start, 5)", "2026-03-06"},
+		{"end - start", "5d"},
+	}
+
+	for _, eq := range session {
+		result, err := run(t, eq.eq, env, evaluator.Options{Backend: evaluator.Float})
+		if err != nil {
+			t.Fatalf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+		}
+		if result.String() != eq.expectedResult {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+		}
+	}
+}
+
+func TestCalendarErrors(t *testing.T) {
+	cases := []struct {
+		eq, message string
+	}{
+		{"2026-03-01 + 2026-03-02", "a date can only be moved by a length of time or subtracted from another date at offset 0"},
+		{"2026-03-01 * 2", "a date can only be moved by a length of time or subtracted from another date at offset 0"},
+		{"2026-03-01 + 5", "only a length of time can be added to a date but had 5 at offset 0"},
+		{"2026-03-01 + 2 km", "only a length of time can be added to a date but had 2 km at offset 0"},
+		{"-2026-03-01", "expected a number but had 2026-03-01 at offset 0"},
+		{"weekday(3)", "weekday expects a date but had 3 at offset 0"},
+		{"workday(2026-03-01, 1.5)", "workday expects a whole number of days but had 1.5 at offset 0"},
+		{"sqrt(2026-03-01)", "expected a number but had 2026-03-01 at offset 0"},
+		{"2026-03-01 + 10000000000000 yr", "the date is outside the years 0 to 9999 at offset 0"},
+		{"2026-03-01 - 3000 yr", "the date is outside the years 0 to 9999 at offset 0"},
+		{"2026-03-01 + 8000 yr", "the date is outside the years 0 to 9999 at offset 0"},
+		{"2026-03-01 + 1e300 s", "the date is outside the years 0 to 9999 at offset 0"},
+	}
+
+	for _, c := range cases {
+		_, err := run(t, c.eq, parser.NewEnv(), evaluator.Options{Backend: evaluator.Float})
+		if err == nil || err.Error() != c.message {
+			t.Errorf("In Equation %s\n Expected the error %q but had %v", c.eq, c.message, err)
+		}
+	}
+
+	var calendar *parser.CalendarError
+	if _, err := run(t, "2026-03-01 + 1 day", parser.NewEnv(), evaluator.Options{Backend: evaluator.Big}); !errors.As(err, &calendar) {
+		t.Errorf("Expected a CalendarError from the Big backend but had %v", err)
+	}
+	if _, err := run(t, "2026-03-01 + 10000000000000 yr", parser.NewEnv(), evaluator.Options{Backend: evaluator.Float}); !errors.As(err, &calendar) {
+		t.Errorf("Expected a CalendarError for a date beyond the year 9999 but had %v", err)
+	}
+}
+
+func TestProgrammerBackend(t *testing.T) {
+	cases := []struct {
+		eq       string
+		size     int
+		unsigned bool
+		expected string
+	}{
+		// Integers wrap around to the word size
+		{"127 + 1", 8, false, "-128"},
+		{"255 + 1", 8, true, "0"},
+		{"0 - 1", 16, true, "65535"},
+		{"2 ^ 31", 32, false, "-2147483648"},
+		{"2 ^ 64 - 1", 64, true, "18446744073709551615"},
+		{"0x1FF", 8, true, "255"},
+		{"-128 / -1", 8, false, "-128"},
+		{"25!", 64, true, "7034535277573963776"},
+		{"20!", 8, true, "0"},
+
+		// Division truncates as in C
+		{"7 / 2", 32, false, "3"},
+		{"-7 / 2", 32, false, "-3"},
+		{"7 % 4", 32, false, "3"},
+		{"-7 % 4", 32, false, "-3"},
+		{"10 % -3", 32, false, "1"},
+		{"10 % ~3", 32, false, "2"},
+		{"2 ^ -1", 32, false, "0"},
+		{"sqrt(17)", 32, false, "4"},
+		{"9.9", 32, false, "9"},
+
+		// Bitwise operators
+		{"0xF0 | 0x0F & 0x3C", 8, true, "252"},
+		{"6 xor 3", 8, true, "5"},
+		{"~0", 8, true, "255"},
+		{"~0", 8, false, "-1"},
+		{"1 << 7", 8, false, "-128"},
+		{"1 << 8", 8, true, "0"},
+		{"-16 >> 2", 8, false, "-4"},
+		{"0xF0 >> 4", 8, true, "15"},
+		{"max(-1, 1)", 8, false, "1"},
+		{"max(-1, 1)", 8, true, "255"},
+	}
+
+	for _, c := range cases {
+		opts := evaluator.Options{Backend: evaluator.Programmer, WordSize: c.size, Unsigned: c.unsigned}
+		result, err := run(t, c.eq, parser.NewEnv(), opts)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", c.eq, err)
+			continue
+		}
+		if decimal := result.(evaluator.IntegerValue).Decimal(); decimal != c.expected {
+			t.Errorf("In Equation %s with %d bits\n Expected result is %s but the result was %s", c.eq, c.size, c.expected, decimal)
+		}
+	}
+
+	for _, eq := range []string{"1 / 0", "5 % 0", "1 << -1"} {
+		opts := evaluator.Options{Backend: evaluator.Programmer}
+		if _, err := run(t, eq, parser.NewEnv(), opts); err == nil {
+			t.Errorf("In Equation %s\n Expected an error", eq)
+		}
+	}
+}
+
+func TestProgrammerBases(t *testing.T) {
+	cases := []struct {
+		eq       string
+		size     int
+		expected string
+	}{
+		{"-1", 8, "DEC -1\nHEX FF\nOCT 377\nBIN 1111 1111"},
+		{"0xBEEF", 16, "DEC -16657\nHEX BEEF\nOCT 137357\nBIN 1011 1110 1110 1111"},
+		{"42", 64, "DEC 42\nHEX 2A\nOCT 52\nBIN 10 1010"},
+	}
+
+	for _, c := range cases {
+		opts := evaluator.Options{Backend: evaluator.Programmer, WordSize: c.size}
+		result, err := run(t, c.eq, parser.NewEnv(), opts)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", c.eq, err)
+			continue
+		}
+		if result.String() != c.expected {
+			t.Errorf("In Equation %s\n Expected result is %q but the result was %q", c.eq, c.expected, result.String())
+		}
+	}
+}
+
+func TestRadix(t *testing.T) {
+	cases := []struct {
+		eq       string
+		opts     evaluator.Options
+		expected string
+	}{
+		{"255 to hex", evaluator.Options{}, "0xFF"},
+		{"0xff to bin", evaluator.Options{}, "0b11111111"},
+		{"8 to oct", evaluator.Options{}, "0o10"},
+		{"0x10 to dec", evaluator.Options{}, "16"},
+		{"100 to base 7", evaluator.Options{}, "202 (base 7)"},
+		{"35 to base 36", evaluator.Options{}, "Z (base 36)"},
+		{"-10.5 to hex", evaluator.Options{}, "-0xA.8"},
+		{"0.1 to bin", evaluator.Options{}, "0b0.00011001100110011001100110011001100110011001100110011"},
+		{"1/3 to base 3", evaluator.Options{Backend: evaluator.Rational}, "0.1 (base 3)"},
+		{"5 km / 1 m to hex", evaluator.Options{}, "0x1388"},
+		{"4+0i to hex", evaluator.Options{Backend: evaluator.Complex}, "0x4"},
+		{"2^70 to hex", evaluator.Options{Backend: evaluator.Big}, "0x400000000000000000"},
+		{"-1 to hex", evaluator.Options{Backend: evaluator.Programmer, WordSize: 16}, "0xFFFF"},
+		{"-1 to dec", evaluator.Options{Backend: evaluator.Programmer, WordSize: 16}, "-1"},
+		{"x = 255 to hex", evaluator.Options{}, "0xFF"},
+		{"(255 to hex) + 1", evaluator.Options{}, "256"},
+	}
+
+	for _, c := range cases {
+		result, err := run(t, c.eq, parser.NewEnv(), c.opts)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", c.eq, err)
+			continue
+		}
+		if result.String() != c.expected {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", c.eq, c.expected, result.String())
+		}
+	}
+
+	invalid := []struct {
+		eq   string
+		opts evaluator.Options
+	}{
+		{"5 km to hex", evaluator.Options{}},
+		{"2026-03-01 to hex", evaluator.Options{}},
+		{"1+2i to hex", evaluator.Options{Backend: evaluator.Complex}},
+		{"[1, 2] to hex", evaluator.Options{Backend: evaluator.Interval}},
+	}
+	for _, c := range invalid {
+		if result, err := run(t, c.eq, parser.NewEnv(), c.opts); err == nil {
+			t.Errorf("In Equation %s\n Expected an error but the result was %s", c.eq, result.String())
+		}
+	}
+
+	// The fraction is rounded to the digits of a float64, not cut.
+	for x, expected := range map[float64]string{1.0 / 3: "0.1 (base 3)", 0.1: "0.0022002200220022002200220022002201 (base 3)"} {
+		if text, _ := evaluator.FormatRadix(evaluator.FloatValue(x), 3); text != expected {
+			t.Errorf("Expected %v in base 3 to be %s but it was %s", x, expected, text)
+		}
+	}
+
+	if _, ok := evaluator.FormatRadix(evaluator.FloatValue(math.Inf(1)), 16); ok {
+		t.Errorf("Expected infinity not to be written in a base")
+	}
+}
+
+func TestFormatter(t *testing.T) {
+	cases := []struct {
+		formatter evaluator.Formatter
+		value     float64
+		expected  string
+	}{
+		{evaluator.Formatter{}, 1234567.5, "1.2345675e+06"},
+		{evaluator.Formatter{}, 0.1, "0.1"},
+		{evaluator.Formatter{Grouping: true}, 1234.5, "1,234.5"},
+		{evaluator.Formatter{Grouping: true}, 1234567.5, "1,234,567.5"},
+		{evaluator.Formatter{Grouping: true}, -1e20, "-100,000,000,000,000,000,000"},
+		{evaluator.Formatter{Grouping: true}, 1e21, "1e+21"},
+		{evaluator.Formatter{}, 1234567.5, "1.2345675e+06"},
+		{evaluator.Formatter{Notation: evaluator.Fixed, Digits: 2}, math.Pi, "3.14"},
+		{evaluator.Formatter{Notation: evaluator.Fixed, Digits: 2, Grouping: true}, -1234567.891, "-1,234,567.89"},
+		{evaluator.Formatter{Notation: evaluator.Fixed}, 2.5, "2"},
+		{evaluator.Formatter{Notation: evaluator.Scientific, Digits: 3}, 123456, "1.235e+05"},
+		{evaluator.Formatter{Notation: evaluator.Scientific, Digits: 2}, -0.00042, "-4.20e-04"},
+		{evaluator.Formatter{Notation: evaluator.Engineering, Digits: 2}, 12345, "12.35e+03"},
+		{evaluator.Formatter{Notation: evaluator.Engineering, Digits: 1}, 0.0047, "4.7e-03"},
+		{evaluator.Formatter{Notation: evaluator.Engineering, Digits: 1}, 999.96, "1.0e+03"},
+		{evaluator.Formatter{Notation: evaluator.Engineering, Digits: 0}, 0, "0e+00"},
+		{evaluator.Formatter{Notation: evaluator.Engineering, Digits: 2}, 99.996, "100.00e+00"},
+		{evaluator.Formatter{Notation: evaluator.Engineering, Digits: 1}, -1234.5, "-1.2e+03"},
+		{evaluator.Formatter{Notation: evaluator.Engineering, Digits: 2}, 5e-324, "4.94e-324"},
+		{evaluator.Formatter{Notation: evaluator.Engineering, Digits: 2}, 1e-310, "100.00e-312"},
+		{evaluator.Formatter{Notation: evaluator.Engineering, Digits: 1, SIPrefix: true}, 4700, "4.7k"},
+		{evaluator.Formatter{Notation: evaluator.Engineering, Digits: 1, SIPrefix: true}, 0.0000022, "2.2µ"},
+		{evaluator.Formatter{Notation: evaluator.Engineering, Digits: 1, SIPrefix: true}, 12, "12.0"},
+		{evaluator.Formatter{Notation: evaluator.Engineering, Digits: 0, SIPrefix: true}, 1e30, "1e+30"},
+		{evaluator.Formatter{Notation: evaluator.Significant, Digits: 3}, 123456, "1.23e+05"},
+		{evaluator.Formatter{Notation: evaluator.Significant, Digits: 3}, 999.996, "1.00e+03"},
+		{evaluator.Formatter{Notation: evaluator.Significant, Digits: 3}, 1234.5, "1.23e+03"},
+		{evaluator.Formatter{Notation: evaluator.Significant, Digits: 3}, 999.4, "999"},
+		{evaluator.Formatter{Notation: evaluator.Significant, Digits: 3}, 1.5, "1.50"},
+		{evaluator.Formatter{Notation: evaluator.Significant, Digits: 3}, 0.000123456, "0.000123"},
+		{evaluator.Formatter{Notation: evaluator.Significant, Digits: 2}, 6.02e23, "6.0e+23"},
+		{evaluator.Formatter{Notation: evaluator.Significant, Digits: 4, Grouping: true}, 1234567, "1.235e+06"},
+		{evaluator.Formatter{Notation: evaluator.Significant, Digits: 7, Grouping: true}, 1234567, "1,234,567"},
+		{evaluator.Formatter{Notation: evaluator.Fixed, Digits: 2}, math.Inf(-1), "-Inf"},
+	}
+
+	for _, c := range cases {
+		if text := c.formatter.Format(c.value); text != c.expected {
+			t.Errorf("Formatting %g with %+v\n Expected %s but had %s", c.value, c.formatter, c.expected, text)
+		}
+	}
+
+	fixed := evaluator.Formatter{Notation: evaluator.Fixed, Digits: 1}
+	result, err := run(t, "5 km / 2 h", parser.NewEnv(), evaluator.Options{})
+	if err != nil {
+		t.Fatalf("Unexpected evaluation error: %v", err)
+	}
+	if text := fixed.FormatResult(result); text != "2.5 km/h" {
+		t.Errorf("Expected the quantity to be formatted as 2.5 km/h but had %s", text)
+	}
+	result, err = run(t, "1/3", parser.NewEnv(), evaluator.Options{Backend: evaluator.Rational})
+	if err != nil {
+		t.Fatalf("Unexpected evaluation error: %v", err)
+	}
+	if text := fixed.FormatResult(result); text != "1/3" {
+		t.Errorf("Expected a fraction to keep its own format but had %s", text)
+	}
+}
+
+func TestFormatterLocale(t *testing.T) {
+	cases := []struct {
+		source   string
+		backend  evaluator.Backend
+		locale   lexer.Locale
+		expected string
+	}{
+		{"1.234,5 * 2", evaluator.Float, lexer.DecimalComma, "2469"},
+		{"max(0,5; 1,25) + 1", evaluator.Float, lexer.DecimalComma, "2,25"},
+		{"round(2,345; 2)", evaluator.Float, lexer.DecimalComma, "2,35"},
+		{"1 234,5 m to km", evaluator.Float, lexer.DecimalCommaSpace, "1,2345 km"},
+		{"1'000.5 / 2", evaluator.Float, lexer.DecimalPointApostrophe, "500.25"},
+		{"[9,5; 10,5] * 2", evaluator.Interval, lexer.DecimalComma, "[19; 21] = 20 ± 1"},
+		{"[9,8; 10,2] * 2", evaluator.Interval, lexer.DecimalComma, "[19,5999999999999; 20,4000000000001] = 20 ± 0,4"},
+		{"1,1 * 3", evaluator.Decimal, lexer.DecimalComma, "3,30"},
+		{"2026-03-01 + 1,5d", evaluator.Float, lexer.DecimalComma, "2026-03-02 12:00"},
+	}
+
+	for _, c := range cases {
+		ast, err := parser.ParseWithOptions(c.source, parser.Options{Locale: c.locale})
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected error: %v", c.source, err)
+			continue
+		}
+		result, err := evaluator.Run(ast, parser.NewEnv(), evaluator.Options{Backend: c.backend, Precision: evaluator.DefaultPrecision, Scale: evaluator.DefaultScale})
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected error: %v", c.source, err)
+			continue
+		}
+		formatter := evaluator.Formatter{Locale: c.locale}
+		if text := formatter.FormatResult(result); text != c.expected {
+			t.Errorf("In Equation %s\n Expected %s but had %s", c.source, c.expected, text)
+		}
+	}
+
+	grouped := evaluator.Formatter{Notation: evaluator.Fixed, Digits: 2, Grouping: true}
+	for locale, expected := range map[lexer.Locale]string{
+		lexer.DecimalPoint:           "-1,234,567.89",
+		lexer.DecimalComma:           "-1.234.567,89",
+		lexer.DecimalCommaSpace:      "-1 234 567,89",
+		lexer.DecimalPointApostrophe: "-1'234'567.89",
+	} {
+		grouped.Locale = locale
+		if text := grouped.Format(-1234567.891); text != expected {
+			t.Errorf("Formatting with %v\n Expected %s but had %s", locale, expected, text)
+		}
+	}
+}
+
+func TestParseNotation(t *testing.T) {
+	for _, notation := range evaluator.Notations {
+		parsed, err := evaluator.ParseNotation(notation.String())
+		if err != nil || parsed != notation {
+			t.Errorf("Expected %v to round trip but had %v, %v", notation, parsed, err)
+		}
+	}
+	if _, err := evaluator.ParseNotation("roman"); err == nil {
+		t.Errorf("Expected an error for an unknown notation")
+	}
+}
+
+func TestBackendVariables(t *testing.T) {
+	env := parser.NewEnv()
+	big := evaluator.Options{Backend: evaluator.Big, Precision: 30}
+	float := evaluator.Options{Backend: evaluator.Float}
+	rational := evaluator.Options{Backend: evaluator.Rational}
+
+	session := []struct {
+		EquationResult
+		opts evaluator.Options
+	}{
+		{EquationResult{"x = 2 ^ 70 + 1", "1180591620717411303425"}, big},
+		{EquationResult{"x - 2 ^ 70", "1"}, big},
+		{EquationResult{"x", "1.1805916207174113e+21"}, float},
+		{EquationResult{"y = 0.1", "0.1"}, float},
+		{EquationResult{"y * 3", "0.3"}, big},
+		{EquationResult{"x = 1", "1"}, float},
+		{EquationResult{"x + 1", "2"}, big},
+		{EquationResult{"z = 1/3", "1/3"}, rational},
+		{EquationResult{"z * 3", "1"}, rational},
+		{EquationResult{"z * 3", "1"}, float},
+	}
+
+	for _, eq := range session {
+		result, err := run(t, eq.eq, env, eq.opts)
+		if err != nil {
+			t.Fatalf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+		}
+		if result.String() != eq.expectedResult {
+			t.Errorf("In Equation %s\n Expected result is %s but the result was %s", eq.eq, eq.expectedResult, result.String())
+		}
+	}
+}
+
+func TestParseBackend(t *testing.T) {
+	for _, backend := range evaluator.Backends {
+		parsed, err := evaluator.ParseBackend(backend.String())
+		if err != nil || parsed != backend {
+			t.Errorf("Expected %v to round trip but had %v, %v", backend, parsed, err)
+		}
+	}
+	if _, err := evaluator.ParseBackend("Abacus"); err == nil {
+		t.Errorf("Expected an error for an unknown backend")
+	}
+}
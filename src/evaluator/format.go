@@ -0,0 +1,230 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package evaluator
+
+import (
+	"calculator/src/lexer"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Notation selects how a Formatter writes numbers.
+type Notation int
+
+const (
+	// General writes the shortest text that reads back as the same float64,
+	// switching to an exponent for very large and very small numbers: "0.1",
+	// "1e+06". With Grouping, numbers below 1e21 keep all their digits
+	// before the point so that they can be grouped: "1,000,000".
+	General Notation = iota
+	// Fixed writes a fixed number of decimal places: "3.14".
+	Fixed
+	// Scientific writes one digit before the point and an exponent: "3.14e+00".
+	Scientific
+	// Engineering writes an exponent that is a multiple of 3, so that it
+	// matches an SI prefix: "12.35e+03", or "12.35k" with SI prefixes.
+	Engineering
+	// Significant writes a number of significant figures, keeping the
+	// trailing zeros that are significant: "1.50".
+	Significant
+)
+
+// Notations lists every Notation in the order they are offered to the user.
+var Notations = []Notation{General, Fixed, Scientific, Engineering, Significant}
+
+// String returns the name the notation is shown with.
+func (n Notation) String() string {
+	switch n {
+	case General:
+		return "general"
+	case Fixed:
+		return "fixed"
+	case Scientific:
+		return "scientific"
+	case Engineering:
+		return "engineering"
+	case Significant:
+		return "significant"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", int(n))
+	}
+}
+
+// ParseNotation returns the Notation whose name is s.
+func ParseNotation(s string) (Notation, error) {
+	for _, notation := range Notations {
+		if notation.String() == s {
+			return notation, nil
+		}
+	}
+	return General, fmt.Errorf("unknown notation %q", s)
+}
+
+// DefaultFormatDigits is the number of digits a Formatter writes when none
+// is configured.
+const DefaultFormatDigits = 6
+
+// siPrefixes are the SI prefixes of the exponents from -24 to 24, by
+// exponent/3 + 8.
+var siPrefixes = []string{"y", "z", "a", "f", "p", "n", "µ", "m", "", "k", "M", "G", "T", "P", "E", "Z", "Y"}
+
+// Formatter writes the numbers of results. Its zero value writes them like
+// fmt's %g verb, as they have always been shown.
+type Formatter struct {
+	// Notation is how numbers are written.
+	Notation Notation
+	// Digits is the number of decimal places in Fixed notation and of the
+	// mantissa in Scientific and Engineering notation, and the number of
+	// significant figures in Significant notation. General ignores it.
+	Digits int
+	// SIPrefix replaces the exponent of Engineering notation with its SI
+	// prefix, as in "4.7k", when there is one.
+	SIPrefix bool
+	// Grouping separates the digits before the point in groups of three,
+	// as in "1,234,567.5".
+	Grouping bool
+	// Locale is the decimal mark, group separator and argument separator
+	// numbers are written with.
+	Locale lexer.Locale
+}
+
+// Format writes x in the notation of f.
+func (f Formatter) Format(x float64) string {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return fmt.Sprintf("%g", x)
+	}
+
+	var text string
+	switch f.Notation {
+	case Fixed:
+		text = strconv.FormatFloat(x, 'f', max(f.Digits, 0), 64)
+	case Scientific:
+		text = strconv.FormatFloat(x, 'e', max(f.Digits, 0), 64)
+	case Engineering:
+		text = f.engineering(x)
+	case Significant:
+		text = significantFigures(x, max(f.Digits, 1))
+	default:
+		text = f.general(x)
+	}
+
+	text = strings.Replace(text, ".", string(f.Locale.Decimal()), 1)
+	if f.Grouping {
+		text = groupThousands(text, f.Locale.Grouping())
+	}
+	return text
+}
+
+// FormatResult writes res with f when it is a number of the Float backend,
+// with or without a unit. The other backends have their own settings for
+// the digits they show, so their results are written as usual in the locale
+// of f.
+func (f Formatter) FormatResult(res Result) string {
+	switch v := res.(type) {
+	case FloatValue:
+		return f.Format(float64(v))
+	case QuantityValue:
+		return v.Format(f)
+	default:
+		return f.Localize(res.String())
+	}
+}
+
+// Localize rewrites a result written with decimal points and commas between
+// its numbers, as in "[9.8, 10.2]", in the locale of f: "[9,8; 10,2]" with
+// lexer.DecimalComma. Only points between two digits are decimal marks.
+func (f Formatter) Localize(text string) string {
+	decimal, separator := f.Locale.Decimal(), f.Locale.Separator()
+	if decimal == '.' && separator == ',' {
+		return text
+	}
+
+	localized := []rune(text)
+	for i, r := range localized {
+		switch {
+		case r == '.' && i > 0 && i+1 < len(localized) && isDigit(localized[i-1]) && isDigit(localized[i+1]):
+			localized[i] = decimal
+		case r == ',':
+			localized[i] = separator
+		}
+	}
+	return string(localized)
+}
+
+// general writes x like fmt's %g verb, but without an exponent for numbers
+// from 1e6 to 1e21 when f groups their digits.
+func (f Formatter) general(x float64) string {
+	if abs := math.Abs(x); f.Grouping && abs >= 1e6 && abs < 1e21 {
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%g", x)
+}
+
+// engineering writes x with an exponent that is a multiple of 3, or with the
+// SI prefix of the exponent when f asks for it.
+func (f Formatter) engineering(x float64) string {
+	digits := max(f.Digits, 0)
+	exponent := 0
+	if x != 0 {
+		// The exponent is read from the text of x, as math.Log10 is not
+		// accurate for subnormal numbers.
+		text := strconv.FormatFloat(x, 'e', -1, 64)
+		e, _ := strconv.Atoi(text[strings.IndexByte(text, 'e')+1:])
+		exponent = 3 * int(math.Floor(float64(e)/3))
+	}
+
+	mantissa := strconv.FormatFloat(scale(x, exponent), 'f', digits, 64)
+	// Rounding can carry the mantissa up to 1000, as 999.96 to "1000.0".
+	if m, _ := strconv.ParseFloat(mantissa, 64); math.Abs(m) >= 1000 {
+		exponent += 3
+		mantissa = strconv.FormatFloat(scale(x, exponent), 'f', digits, 64)
+	}
+
+	if i := exponent/3 + 8; f.SIPrefix && i >= 0 && i < len(siPrefixes) {
+		return mantissa + siPrefixes[i]
+	}
+	return fmt.Sprintf("%se%+03d", mantissa, exponent)
+}
+
+// scale returns x / 10^exponent. The powers of ten of subnormal numbers are
+// 0 as float64, so x is first brought up into the normal range for them.
+func scale(x float64, exponent int) float64 {
+	if exponent < -300 {
+		return x * 1e300 / math.Pow10(exponent+300)
+	}
+	return x / math.Pow10(exponent)
+}
+
+// significantFigures writes x with n significant figures, with a plain
+// decimal point when that shows them all and in Scientific notation
+// otherwise, as the zeros of "1230" for 1234.5 to 3 figures would read as
+// significant.
+func significantFigures(x float64, n int) string {
+	rounded := strconv.FormatFloat(x, 'e', n-1, 64)
+	exponent, _ := strconv.Atoi(rounded[strings.IndexByte(rounded, 'e')+1:])
+	if exponent < -6 || exponent >= n {
+		return rounded
+	}
+	value, _ := strconv.ParseFloat(rounded, 64)
+	return strconv.FormatFloat(value, 'f', max(n-1-exponent, 0), 64)
+}
+
+// groupThousands separates the digits before the point of a formatted number
+// with separator.
+func groupThousands(text string, separator rune) string {
+	start := strings.IndexFunc(text, isDigit)
+	if start < 0 {
+		return text
+	}
+	end := start
+	for end < len(text) && isDigit(rune(text[end])) {
+		end++
+	}
+	return text[:start] + group(text[start:end], 3, separator) + text[end:]
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
@@ -0,0 +1,338 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package evaluator
+
+import (
+	"calculator/src/lexer"
+	"calculator/src/parser"
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// WordSizes lists the number of bits the Programmer backend can compute with,
+// in the order they are offered to the user.
+var WordSizes = []int{8, 16, 32, 64}
+
+// DefaultWordSize is the number of bits of the Programmer backend when none is
+// configured.
+const DefaultWordSize = 64
+
+// IntegerValue is a value of the Programmer backend: an integer of Size bits
+// that wraps around on overflow, as in the registers of a processor.
+type IntegerValue struct {
+	// Bits holds the value in two's complement, with the bits above Size clear.
+	Bits uint64
+	// Size is the number of bits of the value.
+	Size int
+	// Unsigned is set when the value is read as an unsigned integer.
+	Unsigned bool
+}
+
+// String returns the value in decimal, hexadecimal, octal and binary, one per
+// line. Hexadecimal and binary digits are grouped in fours.
+func (v IntegerValue) String() string {
+	return strings.Join([]string{
+		"DEC " + v.Decimal(),
+		"HEX " + group(strings.ToUpper(strconv.FormatUint(v.Bits, 16)), 4, ' '),
+		"OCT " + strconv.FormatUint(v.Bits, 8),
+		"BIN " + group(strconv.FormatUint(v.Bits, 2), 4, ' '),
+	}, "\n")
+}
+
+// Decimal returns the value in decimal, negative when it is signed and its
+// highest bit is set.
+func (v IntegerValue) Decimal() string {
+	if v.Unsigned {
+		return strconv.FormatUint(v.Bits, 10)
+	}
+	return strconv.FormatInt(v.Int64(), 10)
+}
+
+// Int64 returns the value with its sign extended to 64 bits. Unsigned values
+// of 64 bits above math.MaxInt64 are negative.
+func (v IntegerValue) Int64() int64 {
+	shift := 64 - v.Size
+	return int64(v.Bits<<shift) >> shift
+}
+func (v IntegerValue) Float64() float64 {
+	if v.Unsigned {
+		return float64(v.Bits)
+	}
+	return float64(v.Int64())
+}
+
+// group separates digits in groups of size from the right with separator,
+// as in "1 0101".
+func group(digits string, size int, separator rune) string {
+	var grouped strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%size == 0 {
+			grouped.WriteRune(separator)
+		}
+		grouped.WriteRune(digit)
+	}
+	return grouped.String()
+}
+
+// errIntegerDivisionByZero is returned by a division or remainder by zero,
+// which has no integer result.
+var errIntegerDivisionByZero = errors.New("integer division by zero")
+
+// integerArithmetic implements Arithmetic over IntegerValue.
+// Operations on integers wrap around to the word size; operations with no
+// integer counterpart, such as sin, are computed in float64 and truncated.
+type integerArithmetic struct {
+	size     int
+	unsigned bool
+	env      *parser.Env
+}
+
+// NewIntegerArithmetic creates the Programmer backend arithmetic computing
+// with integers of size bits, signed unless unsigned is set, reading the angle
+// mode from env. Sizes other than those of WordSizes use DefaultWordSize.
+func NewIntegerArithmetic(size int, unsigned bool, env *parser.Env) Arithmetic[IntegerValue] {
+	if size <= 0 || size > 64 || size%8 != 0 {
+		size = DefaultWordSize
+	}
+	return integerArithmetic{size: size, unsigned: unsigned, env: env}
+}
+
+// wrap returns the integer whose lowest bits are those of b.
+func (a integerArithmetic) wrap(b uint64) IntegerValue {
+	return IntegerValue{Bits: b & (math.MaxUint64 >> (64 - a.size)), Size: a.size, Unsigned: a.unsigned}
+}
+
+// word returns v with the size and signedness of a. A value assigned with
+// another word size keeps its sign and is wrapped to the new size.
+func (a integerArithmetic) word(v IntegerValue) IntegerValue {
+	if v.Size == a.size && v.Unsigned == a.unsigned {
+		return v
+	}
+	if v.Unsigned {
+		return a.wrap(v.Bits)
+	}
+	return a.wrap(uint64(v.Int64()))
+}
+
+// less reports whether x is below y, both of the word of a.
+func (a integerArithmetic) less(x, y IntegerValue) bool {
+	if a.unsigned {
+		return x.Bits < y.Bits
+	}
+	return x.Int64() < y.Int64()
+}
+
+func (a integerArithmetic) Literal(n parser.NumberExpr) (IntegerValue, error) {
+	if n.Literal == "" {
+		return a.FromFloat(n.Value)
+	}
+
+	digits, base := parser.SplitNumber(n.Literal)
+	if base != 10 || !strings.ContainsAny(digits, ".eE") {
+		i, ok := new(big.Int).SetString(digits, base)
+		if !ok {
+			return IntegerValue{}, errors.New("invalid number " + n.Literal)
+		}
+		// Literals too large for the word keep their lowest bits.
+		return a.wrap(new(big.Int).And(i, new(big.Int).SetUint64(math.MaxUint64)).Uint64()), nil
+	}
+	x, err := n.Float64()
+	if err != nil {
+		return IntegerValue{}, err
+	}
+	return a.FromFloat(x)
+}
+
+// FromFloat truncates x towards zero and wraps it to the word size.
+func (a integerArithmetic) FromFloat(x float64) (IntegerValue, error) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return IntegerValue{}, errNotReal
+	}
+	i, _ := big.NewFloat(math.Trunc(x)).Int(nil)
+	return a.wrap(i.And(i, new(big.Int).SetUint64(math.MaxUint64)).Uint64()), nil
+}
+
+func (a integerArithmetic) Binary(op lexer.TokenKind, x, y IntegerValue) (IntegerValue, error) {
+	x, y = a.word(x), a.word(y)
+
+	switch op {
+	case lexer.PLUS:
+		return a.wrap(x.Bits + y.Bits), nil
+	case lexer.DASH:
+		return a.wrap(x.Bits - y.Bits), nil
+	case lexer.STAR:
+		return a.wrap(x.Bits * y.Bits), nil
+	case lexer.SLASH, lexer.PERCENT:
+		return a.divide(op, x, y)
+	case lexer.HAT:
+		return a.pow(x, y)
+	case lexer.AMPERSAND:
+		return a.wrap(x.Bits & y.Bits), nil
+	case lexer.PIPE:
+		return a.wrap(x.Bits | y.Bits), nil
+	case lexer.XOR:
+		return a.wrap(x.Bits ^ y.Bits), nil
+	case lexer.SHIFT_LEFT, lexer.SHIFT_RIGHT:
+		return a.shift(op, x, y)
+	}
+
+	return a.approximate(func(args []float64) (float64, error) {
+		return parser.BinaryOp(op, args[0], args[1])
+	}, x, y)
+}
+
+// divide returns the quotient or the remainder of x and y, truncated towards
+// zero as in C, so that the remainder of unsigned integers is never negative.
+func (a integerArithmetic) divide(op lexer.TokenKind, x, y IntegerValue) (IntegerValue, error) {
+	if y.Bits == 0 {
+		return IntegerValue{}, errIntegerDivisionByZero
+	}
+	if a.unsigned {
+		if op == lexer.SLASH {
+			return a.wrap(x.Bits / y.Bits), nil
+		}
+		return a.wrap(x.Bits % y.Bits), nil
+	}
+
+	// The smallest integer divided by -1 wraps around to itself.
+	if op == lexer.SLASH {
+		return a.wrap(uint64(x.Int64() / y.Int64())), nil
+	}
+	return a.wrap(uint64(x.Int64() % y.Int64())), nil
+}
+
+// pow returns x^y by repeated squaring, wrapping around at every step.
+// Negative exponents truncate 1/x^-y to an integer.
+func (a integerArithmetic) pow(x, y IntegerValue) (IntegerValue, error) {
+	if !a.unsigned && y.Int64() < 0 {
+		switch x.Int64() {
+		case 0:
+			return IntegerValue{}, errIntegerDivisionByZero
+		case 1:
+			return a.wrap(1), nil
+		case -1:
+			return a.wrap(uint64(1 - 2*(y.Int64()&1))), nil
+		}
+		return a.wrap(0), nil
+	}
+
+	result, base := uint64(1), x.Bits
+	for exponent := y.Bits; exponent > 0; exponent >>= 1 {
+		if exponent&1 == 1 {
+			result *= base
+		}
+		base *= base
+	}
+	return a.wrap(result), nil
+}
+
+// shift returns x shifted by y bits. A right shift keeps the sign of signed
+// integers and fills unsigned ones with zeros.
+func (a integerArithmetic) shift(op lexer.TokenKind, x, y IntegerValue) (IntegerValue, error) {
+	if !a.unsigned && y.Int64() < 0 {
+		return IntegerValue{}, errors.New("cannot shift by a negative number of bits")
+	}
+	n := min(y.Bits, 64)
+
+	switch {
+	case op == lexer.SHIFT_LEFT:
+		return a.wrap(x.Bits << n), nil
+	case a.unsigned:
+		return a.wrap(x.Bits >> n), nil
+	default:
+		return a.wrap(uint64(x.Int64() >> n)), nil
+	}
+}
+
+func (a integerArithmetic) Unary(op lexer.TokenKind, x IntegerValue) (IntegerValue, error) {
+	x = a.word(x)
+
+	switch op {
+	case lexer.DASH:
+		return a.wrap(-x.Bits), nil
+	case lexer.TILDE:
+		return a.wrap(^x.Bits), nil
+	}
+
+	return a.approximate(func(args []float64) (float64, error) {
+		return parser.UnaryOp(op, args[0])
+	}, x)
+}
+
+func (a integerArithmetic) Postfix(op lexer.TokenKind, x IntegerValue) (IntegerValue, error) {
+	x = a.word(x)
+
+	switch op {
+	case lexer.BANG:
+		if !a.unsigned && x.Int64() < 0 {
+			return IntegerValue{}, errors.New("factorial of a negative number")
+		}
+		// From 2*size! on, the product has at least size factors of 2.
+		if x.Bits >= uint64(2*a.size) {
+			return a.wrap(0), nil
+		}
+		result := uint64(1)
+		for i := uint64(2); i <= x.Bits; i++ {
+			result *= i
+		}
+		return a.wrap(result), nil
+	case lexer.PERCENT:
+		return a.divide(lexer.SLASH, x, a.wrap(100))
+	}
+
+	return a.approximate(func(args []float64) (float64, error) {
+		return parser.PostfixOp(a.env, op, args[0])
+	}, x)
+}
+
+func (a integerArithmetic) Call(name string, args []IntegerValue) (IntegerValue, error) {
+	for i, arg := range args {
+		args[i] = a.word(arg)
+	}
+
+	switch name {
+	case "abs":
+		if !a.unsigned && args[0].Int64() < 0 {
+			return a.wrap(-args[0].Bits), nil
+		}
+		return args[0], nil
+	case "floor", "ceil", "round":
+		// Integers are already whole, whatever the number of places.
+		return args[0], nil
+	case "sqrt":
+		if a.unsigned || args[0].Int64() >= 0 {
+			root := new(big.Int).Sqrt(new(big.Int).SetUint64(args[0].Bits))
+			return a.wrap(root.Uint64()), nil
+		}
+	case "min", "max":
+		result := args[0]
+		for _, arg := range args[1:] {
+			if (name == "min" && a.less(arg, result)) || (name == "max" && a.less(result, arg)) {
+				result = arg
+			}
+		}
+		return result, nil
+	}
+
+	return a.approximate(func(floats []float64) (float64, error) {
+		return parser.CallFunction(a.env, name, floats)
+	}, args...)
+}
+
+// approximate computes fn over the float64 values of args and truncates the
+// result to an integer.
+func (a integerArithmetic) approximate(fn func(args []float64) (float64, error), args ...IntegerValue) (IntegerValue, error) {
+	floats := make([]float64, len(args))
+	for i, arg := range args {
+		floats[i] = arg.Float64()
+	}
+
+	result, err := fn(floats)
+	if err != nil {
+		return IntegerValue{}, err
+	}
+	return a.FromFloat(result)
+}
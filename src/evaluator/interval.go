@@ -0,0 +1,628 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package evaluator
+
+import (
+	"calculator/src/lexer"
+	"calculator/src/parser"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// IntervalValue is a value of the Interval backend: every number from Lo to
+// Hi, bounds included. Infinite bounds stand for unbounded ranges.
+type IntervalValue struct {
+	Lo float64
+	Hi float64
+}
+
+// String returns the bounds followed by the midpoint and half-width, such as
+// "[1, 2] = 1.5 ± 0.5". The bounds are written with 15 digits, the lower one
+// rounded down and the upper one rounded up, so the interval shown still
+// contains the exact value: "1/3" is "[0.333333333333333, 0.333333333333334]".
+// The midpoint and half-width are only a summary and are shown with fewer
+// digits, hiding the ulps added by outward rounding.
+func (v IntervalValue) String() string {
+	return fmt.Sprintf("[%s, %s] = %s ± %s", formatBound(v.Lo, 15, true), formatBound(v.Hi, 15, false), formatMid(v.Mid()), formatMid(v.Radius()))
+}
+
+// Float64 returns the midpoint.
+func (v IntervalValue) Float64() float64 {
+	return v.Mid()
+}
+
+// Mid returns the midpoint of the interval.
+func (v IntervalValue) Mid() float64 {
+	if math.IsInf(v.Lo, -1) && math.IsInf(v.Hi, 1) {
+		return 0
+	}
+	return v.Lo/2 + v.Hi/2
+}
+
+// Radius returns the half-width of the interval, rounded up.
+func (v IntervalValue) Radius() float64 {
+	if math.IsInf(v.Lo, 0) || math.IsInf(v.Hi, 0) {
+		return math.Inf(1)
+	}
+	return math.Max(subUp(v.Hi, v.Mid()), subUp(v.Mid(), v.Lo))
+}
+
+// Contains reports whether x lies in the interval.
+func (v IntervalValue) Contains(x float64) bool {
+	return v.Lo <= x && x <= v.Hi
+}
+
+// formatBound writes x with digits significant digits, rounded down when
+// down is set and up otherwise.
+func formatBound(x float64, digits int, down bool) string {
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return strconv.FormatFloat(x, 'g', digits, 64)
+	}
+
+	exact := new(big.Rat).SetFloat64(x)
+	direction := math.Inf(1)
+	if down {
+		direction = math.Inf(-1)
+	}
+	// Rounding to nearest is off by less than a unit in the last digit, so
+	// stepping towards direction soon reaches a number that rounds past x.
+	for y := x; ; y = math.Nextafter(y, direction) {
+		text := strconv.FormatFloat(y, 'g', digits, 64)
+		written, _ := new(big.Rat).SetString(text)
+		if cmp := written.Cmp(exact); cmp == 0 || (cmp < 0) == down {
+			return text
+		}
+	}
+}
+
+func formatMid(x float64) string {
+	return strconv.FormatFloat(x, 'g', 12, 64)
+}
+
+// errEmptyInterval is returned for an operation with no result at all, such
+// as a division by exactly zero or the square root of a negative interval.
+var errEmptyInterval = errors.New("result is an empty interval")
+
+// entire is the interval of every real number.
+var entire = IntervalValue{math.Inf(-1), math.Inf(1)}
+
+// intervalArithmetic implements Arithmetic over IntervalValue.
+// Arithmetic operators round the lower bound down and the upper bound up so
+// results contain the exact value; library functions such as sin, which are
+// not correctly rounded, are widened by a few units in the last place.
+type intervalArithmetic struct {
+	env *parser.Env
+}
+
+// NewIntervalArithmetic creates the Interval backend arithmetic, reading the
+// angle mode from env.
+func NewIntervalArithmetic(env *parser.Env) Arithmetic[IntervalValue] {
+	return intervalArithmetic{env: env}
+}
+
+// point returns the interval holding only x.
+func point(x float64) IntervalValue {
+	return IntervalValue{x, x}
+}
+
+// widen returns an interval around a result computed to within ulps units in
+// the last place.
+func widen(x float64, ulps int) IntervalValue {
+	lo, hi := x, x
+	for range ulps {
+		lo, hi = math.Nextafter(lo, math.Inf(-1)), math.Nextafter(hi, math.Inf(1))
+	}
+	return IntervalValue{lo, hi}
+}
+
+// checked rejects intervals with a NaN bound.
+func checked(v IntervalValue) (IntervalValue, error) {
+	if math.IsNaN(v.Lo) || math.IsNaN(v.Hi) {
+		return IntervalValue{}, errNotReal
+	}
+	return v, nil
+}
+
+// Literal returns the smallest interval of float64 bounds holding the number
+// as written, which is a single point when it is exactly representable.
+func (a intervalArithmetic) Literal(n parser.NumberExpr) (IntervalValue, error) {
+	if _, err := n.Float64(); err != nil {
+		return IntervalValue{}, err
+	}
+	result := point(n.Value)
+
+	digits, base := parser.SplitNumber(n.Literal)
+	if base != 10 || math.IsInf(n.Value, 0) {
+		return result, nil
+	}
+	exact, ok := new(big.Rat).SetString(digits)
+	if !ok {
+		return result, nil
+	}
+	switch new(big.Rat).SetFloat64(n.Value).Cmp(exact) {
+	case 1:
+		result.Lo = math.Nextafter(n.Value, math.Inf(-1))
+	case -1:
+		result.Hi = math.Nextafter(n.Value, math.Inf(1))
+	}
+	return result, nil
+}
+
+func (a intervalArithmetic) FromFloat(x float64) (IntervalValue, error) {
+	if math.IsNaN(x) {
+		return IntervalValue{}, errNotReal
+	}
+	return point(x), nil
+}
+
+// Constant widens the irrational mathematical constants by one unit in the
+// last place so they contain the exact value.
+func (a intervalArithmetic) Constant(name string) (IntervalValue, bool) {
+	switch name {
+	case "pi", "tau", "e", "phi":
+		constant, _ := parser.LookupConstant(name)
+		return widen(constant.Value, 1), true
+	}
+	return IntervalValue{}, false
+}
+
+func (a intervalArithmetic) PlusMinus(value, tolerance IntervalValue) (IntervalValue, error) {
+	radius := math.Max(math.Abs(tolerance.Lo), math.Abs(tolerance.Hi))
+	return checked(IntervalValue{subDown(value.Lo, radius), addUp(value.Hi, radius)})
+}
+
+func (a intervalArithmetic) Between(lo, hi IntervalValue) (IntervalValue, error) {
+	if lo.Lo > hi.Hi {
+		return IntervalValue{}, fmt.Errorf("lower bound %s is above upper bound %s", formatBound(lo.Lo, 15, true), formatBound(hi.Hi, 15, false))
+	}
+	return IntervalValue{lo.Lo, hi.Hi}, nil
+}
+
+func (a intervalArithmetic) Binary(op lexer.TokenKind, x, y IntervalValue) (IntervalValue, error) {
+	switch op {
+	case lexer.PLUS:
+		return checked(IntervalValue{addDown(x.Lo, y.Lo), addUp(x.Hi, y.Hi)})
+	case lexer.DASH:
+		return checked(IntervalValue{subDown(x.Lo, y.Hi), subUp(x.Hi, y.Lo)})
+	case lexer.STAR:
+		return checked(multiply(x, y))
+	case lexer.SLASH:
+		return divide(x, y)
+	case lexer.HAT:
+		return power(x, y)
+	case lexer.ROOT:
+		inverse, err := divide(point(1), y)
+		if err != nil {
+			return IntervalValue{}, err
+		}
+		return power(x, inverse)
+	case lexer.LOG:
+		num, err := a.Call("ln", []IntervalValue{x})
+		if err != nil {
+			return IntervalValue{}, err
+		}
+		den, err := a.Call("ln", []IntervalValue{y})
+		if err != nil {
+			return IntervalValue{}, err
+		}
+		return divide(num, den)
+	case lexer.PERCENT:
+		if x.Lo == x.Hi && y.Lo == y.Hi {
+			result, err := parser.BinaryOp(op, x.Lo, y.Lo)
+			return point(result), err
+		}
+		return IntervalValue{}, errors.New("remainder is only defined for exact numbers in the Interval backend")
+	default:
+		return IntervalValue{}, fmt.Errorf("operator %s not recognized", lexer.TokenKindString(op))
+	}
+}
+
+func (a intervalArithmetic) Unary(op lexer.TokenKind, x IntervalValue) (IntervalValue, error) {
+	if op == lexer.DASH {
+		return IntervalValue{-x.Hi, -x.Lo}, nil
+	}
+	return IntervalValue{}, fmt.Errorf("operator %s not recognized", lexer.TokenKindString(op))
+}
+
+func (a intervalArithmetic) Postfix(op lexer.TokenKind, x IntervalValue) (IntervalValue, error) {
+	switch op {
+	case lexer.PERCENT:
+		return divide(x, point(100))
+	case lexer.DEGREE:
+		factor := a.env.Angle.FromRadians(parser.Degrees.ToRadians(1))
+		if factor == 1 {
+			return x, nil
+		}
+		return checked(multiply(x, widen(factor, 2)))
+	case lexer.BANG:
+		if x.Lo == x.Hi {
+			return checked(widen(parser.Factorial(x.Lo), 4))
+		}
+		// x! = Gamma(x+1) increases from its minimum near x = 0.4616.
+		if x.Lo < 0.5 {
+			return IntervalValue{}, errors.New("factorial of an interval needs bounds of at least 0.5")
+		}
+		return monotonic(parser.Factorial, x, true, 4)
+	default:
+		return IntervalValue{}, fmt.Errorf("operator %s not recognized", lexer.TokenKindString(op))
+	}
+}
+
+func (a intervalArithmetic) Call(name string, args []IntervalValue) (IntervalValue, error) {
+	x := args[0]
+	switch name {
+	case "sqrt":
+		if x.Hi < 0 {
+			return IntervalValue{}, errEmptyInterval
+		}
+		return IntervalValue{sqrtDown(math.Max(x.Lo, 0)), sqrtUp(x.Hi)}, nil
+	case "ln":
+		if x.Hi <= 0 {
+			return IntervalValue{}, errEmptyInterval
+		}
+		return monotonic(math.Log, IntervalValue{math.Max(x.Lo, 0), x.Hi}, true, 2)
+	case "exp":
+		result, err := monotonic(math.Exp, x, true, 2)
+		result.Lo = math.Max(result.Lo, 0)
+		return result, err
+	case "abs":
+		switch {
+		case x.Lo >= 0:
+			return x, nil
+		case x.Hi <= 0:
+			return IntervalValue{-x.Hi, -x.Lo}, nil
+		default:
+			return IntervalValue{0, math.Max(-x.Lo, x.Hi)}, nil
+		}
+	case "floor":
+		return IntervalValue{math.Floor(x.Lo), math.Floor(x.Hi)}, nil
+	case "ceil":
+		return IntervalValue{math.Ceil(x.Lo), math.Ceil(x.Hi)}, nil
+	case "re", "conj":
+		return x, nil
+	case "round":
+		if len(args) == 2 && args[1].Lo != args[1].Hi {
+			return IntervalValue{}, errors.New("round needs an exact number of digits")
+		}
+		lo, _ := parser.CallFunction(a.env, name, append([]float64{x.Lo}, bounds(args[1:])...))
+		hi, _ := parser.CallFunction(a.env, name, append([]float64{x.Hi}, bounds(args[1:])...))
+		return IntervalValue{lo, hi}, nil
+	case "im":
+		return point(0), nil
+	case "min", "max":
+		result := x
+		for _, arg := range args[1:] {
+			if name == "min" {
+				result = IntervalValue{math.Min(result.Lo, arg.Lo), math.Min(result.Hi, arg.Hi)}
+			} else {
+				result = IntervalValue{math.Max(result.Lo, arg.Lo), math.Max(result.Hi, arg.Hi)}
+			}
+		}
+		return result, nil
+	case "sin", "cos":
+		return a.sinCos(name, x)
+	case "tan":
+		return a.tan(x)
+	case "asin", "acos":
+		if x.Hi < -1 || x.Lo > 1 {
+			return IntervalValue{}, errEmptyInterval
+		}
+		clipped := IntervalValue{math.Max(x.Lo, -1), math.Min(x.Hi, 1)}
+		inverse := map[string]func(float64) float64{"asin": math.Asin, "acos": math.Acos}[name]
+		result, err := monotonic(inverse, clipped, name == "asin", 2)
+		if err != nil {
+			return IntervalValue{}, err
+		}
+		return a.fromRadians(result), nil
+	case "atan":
+		result, err := monotonic(math.Atan, x, true, 2)
+		if err != nil {
+			return IntervalValue{}, err
+		}
+		return a.fromRadians(result), nil
+	case "arg":
+		switch {
+		case x.Lo >= 0:
+			return point(0), nil
+		case x.Hi < 0:
+			return a.fromRadians(widen(math.Pi, 1)), nil
+		default:
+			return a.fromRadians(IntervalValue{0, math.Nextafter(math.Pi, math.Inf(1))}), nil
+		}
+	default:
+		return IntervalValue{}, fmt.Errorf("%s is not defined for intervals", name)
+	}
+}
+
+// sinCos computes sin or cos over an interval of angles in the angle mode,
+// including the extremes of ±1 when the interval reaches one.
+func (a intervalArithmetic) sinCos(name string, x IntervalValue) (IntervalValue, error) {
+	r, err := a.toRadians(x)
+	if err != nil {
+		return IntervalValue{}, err
+	}
+	if name == "cos" {
+		// cos(x) = sin(x + pi/2)
+		r = IntervalValue{addDown(r.Lo, math.Pi/2), addUp(r.Hi, math.Nextafter(math.Pi/2, math.Inf(1)))}
+	}
+	if r.Hi-r.Lo >= 2*math.Pi {
+		return IntervalValue{-1, 1}, nil
+	}
+
+	lo, hi := math.Sin(r.Lo), math.Sin(r.Hi)
+	result := IntervalValue{math.Min(lo, hi), math.Max(lo, hi)}
+	result = IntervalValue{widen(result.Lo, 2).Lo, widen(result.Hi, 2).Hi}
+	// The maxima are at pi/2 + 2k*pi and the minima at -pi/2 + 2k*pi.
+	if containsPhase(r, math.Pi/2) {
+		result.Hi = 1
+	}
+	if containsPhase(r, -math.Pi/2) {
+		result.Lo = -1
+	}
+	return IntervalValue{math.Max(result.Lo, -1), math.Min(result.Hi, 1)}, nil
+}
+
+// tan computes tan over an interval of angles, which must not contain an
+// asymptote at pi/2 + k*pi.
+func (a intervalArithmetic) tan(x IntervalValue) (IntervalValue, error) {
+	r, err := a.toRadians(x)
+	if err != nil {
+		return IntervalValue{}, err
+	}
+	if r.Hi-r.Lo >= math.Pi || containsPhase(r, math.Pi/2) || containsPhase(r, -math.Pi/2) {
+		return entire, nil
+	}
+	return monotonic(math.Tan, r, true, 2)
+}
+
+// containsPhase reports whether r holds an angle phase + 2k*pi for some integer k.
+func containsPhase(r IntervalValue, phase float64) bool {
+	k := math.Ceil((r.Lo - phase) / (2 * math.Pi))
+	return phase+2*math.Pi*k <= r.Hi
+}
+
+func (a intervalArithmetic) toRadians(x IntervalValue) (IntervalValue, error) {
+	factor := a.env.Angle.ToRadians(1)
+	if factor == 1 {
+		return x, nil
+	}
+	return checked(multiply(x, widen(factor, 2)))
+}
+
+func (a intervalArithmetic) fromRadians(x IntervalValue) IntervalValue {
+	factor := a.env.Angle.FromRadians(1)
+	if factor == 1 {
+		return x
+	}
+	return multiply(x, widen(factor, 2))
+}
+
+// monotonic applies fn, increasing or decreasing over x, to the bounds of x,
+// widening the results by ulps units in the last place.
+func monotonic(fn func(float64) float64, x IntervalValue, increasing bool, ulps int) (IntervalValue, error) {
+	lo, hi := fn(x.Lo), fn(x.Hi)
+	if !increasing {
+		lo, hi = hi, lo
+	}
+	return checked(IntervalValue{widen(lo, ulps).Lo, widen(hi, ulps).Hi})
+}
+
+func bounds(args []IntervalValue) []float64 {
+	values := make([]float64, len(args))
+	for i, arg := range args {
+		values[i] = arg.Lo
+	}
+	return values
+}
+
+// multiply returns the product of two intervals, taking 0 * Inf as 0.
+func multiply(x, y IntervalValue) IntervalValue {
+	products := [4][2]float64{
+		{mulDown(x.Lo, y.Lo), mulUp(x.Lo, y.Lo)},
+		{mulDown(x.Lo, y.Hi), mulUp(x.Lo, y.Hi)},
+		{mulDown(x.Hi, y.Lo), mulUp(x.Hi, y.Lo)},
+		{mulDown(x.Hi, y.Hi), mulUp(x.Hi, y.Hi)},
+	}
+	result := IntervalValue{products[0][0], products[0][1]}
+	for _, p := range products[1:] {
+		result.Lo, result.Hi = math.Min(result.Lo, p[0]), math.Max(result.Hi, p[1])
+	}
+	return result
+}
+
+// divide returns the quotient of two intervals. A divisor containing zero
+// gives an unbounded result: half-infinite when zero is one of its bounds
+// and every real number when it lies inside.
+func divide(x, y IntervalValue) (IntervalValue, error) {
+	if !y.Contains(0) {
+		quotients := [4][2]float64{
+			{divDown(x.Lo, y.Lo), divUp(x.Lo, y.Lo)},
+			{divDown(x.Lo, y.Hi), divUp(x.Lo, y.Hi)},
+			{divDown(x.Hi, y.Lo), divUp(x.Hi, y.Lo)},
+			{divDown(x.Hi, y.Hi), divUp(x.Hi, y.Hi)},
+		}
+		result := IntervalValue{quotients[0][0], quotients[0][1]}
+		for _, q := range quotients[1:] {
+			result.Lo, result.Hi = math.Min(result.Lo, q[0]), math.Max(result.Hi, q[1])
+		}
+		return checked(result)
+	}
+
+	inf := math.Inf(1)
+	switch {
+	case y.Lo == 0 && y.Hi == 0:
+		return IntervalValue{}, errDivisionByZero
+	case x.Contains(0), y.Lo < 0 && y.Hi > 0:
+		return entire, nil
+	case x.Hi < 0 && y.Lo == 0:
+		return IntervalValue{-inf, divUp(x.Hi, y.Hi)}, nil
+	case x.Hi < 0:
+		return IntervalValue{divDown(x.Hi, y.Lo), inf}, nil
+	case y.Lo == 0:
+		return IntervalValue{divDown(x.Lo, y.Hi), inf}, nil
+	default:
+		return IntervalValue{-inf, divUp(x.Lo, y.Lo)}, nil
+	}
+}
+
+// power raises x to the power y. Integer exponents accept any base; other
+// exponents need a base that is not negative.
+func power(x, y IntervalValue) (IntervalValue, error) {
+	if y.Lo == y.Hi && y.Lo == math.Trunc(y.Lo) && math.Abs(y.Lo) <= 1<<53 {
+		return integerPower(x, int64(y.Lo))
+	}
+	if x.Lo < 0 {
+		return IntervalValue{}, errors.New("a negative base needs an integer exponent in the Interval backend")
+	}
+
+	// x^y is monotonic in each argument on x >= 0, so the extremes are at the corners.
+	corners := []float64{math.Pow(x.Lo, y.Lo), math.Pow(x.Lo, y.Hi), math.Pow(x.Hi, y.Lo), math.Pow(x.Hi, y.Hi)}
+	result := IntervalValue{corners[0], corners[0]}
+	for _, c := range corners[1:] {
+		result.Lo, result.Hi = math.Min(result.Lo, c), math.Max(result.Hi, c)
+	}
+	return checked(IntervalValue{math.Max(widen(result.Lo, 2).Lo, 0), widen(result.Hi, 2).Hi})
+}
+
+func integerPower(x IntervalValue, n int64) (IntervalValue, error) {
+	if n < 0 {
+		positive, err := integerPower(x, -n)
+		if err != nil {
+			return IntervalValue{}, err
+		}
+		return divide(point(1), positive)
+	}
+	if n == 0 {
+		return point(1), nil
+	}
+
+	// Even powers fold the negative half onto the positive one.
+	if n%2 == 0 {
+		switch {
+		case x.Hi < 0:
+			x = IntervalValue{-x.Hi, -x.Lo}
+		case x.Lo < 0:
+			return integerPower(IntervalValue{0, math.Max(-x.Lo, x.Hi)}, n)
+		}
+	}
+
+	// Odd powers and powers of a non-negative base are increasing.
+	lo, hi := point(x.Lo), point(x.Hi)
+	resultLo, resultHi := point(1), point(1)
+	for e := n; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			resultLo, resultHi = multiply(resultLo, lo), multiply(resultHi, hi)
+		}
+		lo, hi = multiply(lo, lo), multiply(hi, hi)
+	}
+	return checked(IntervalValue{resultLo.Lo, resultHi.Hi})
+}
+
+// The functions below compute a correctly rounded operation and then step
+// to the neighbouring float64 when the exact result lies on that side of it.
+// The exact error of the rounded result is found with an error-free
+// transformation: TwoSum for additions and fused multiply-add otherwise.
+
+func addDown(a, b float64) float64 {
+	s, e := twoSum(a, b)
+	if e < 0 {
+		return math.Nextafter(s, math.Inf(-1))
+	}
+	return s
+}
+
+func addUp(a, b float64) float64 {
+	s, e := twoSum(a, b)
+	if e > 0 {
+		return math.Nextafter(s, math.Inf(1))
+	}
+	return s
+}
+
+func subDown(a, b float64) float64 {
+	return addDown(a, -b)
+}
+
+func subUp(a, b float64) float64 {
+	return addUp(a, -b)
+}
+
+// twoSum returns a+b rounded and the error of the rounding, so that the
+// exact sum is s + e.
+func twoSum(a, b float64) (float64, float64) {
+	s := a + b
+	if math.IsInf(s, 0) || math.IsNaN(s) {
+		return s, 0
+	}
+	bb := s - a
+	return s, (a - (s - bb)) + (b - bb)
+}
+
+func mulDown(a, b float64) float64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	p := a * b
+	if !math.IsInf(p, 0) && math.FMA(a, b, -p) < 0 {
+		return math.Nextafter(p, math.Inf(-1))
+	}
+	return p
+}
+
+func mulUp(a, b float64) float64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	p := a * b
+	if !math.IsInf(p, 0) && math.FMA(a, b, -p) > 0 {
+		return math.Nextafter(p, math.Inf(1))
+	}
+	return p
+}
+
+// divError returns the sign of a/b - q.
+func divError(a, b, q float64) float64 {
+	if math.IsInf(q, 0) || math.IsInf(a, 0) || math.IsInf(b, 0) || q == 0 && a == 0 {
+		return 0
+	}
+	r := math.FMA(-q, b, a)
+	if b < 0 {
+		return -r
+	}
+	return r
+}
+
+func divDown(a, b float64) float64 {
+	q := a / b
+	if divError(a, b, q) < 0 {
+		return math.Nextafter(q, math.Inf(-1))
+	}
+	return q
+}
+
+func divUp(a, b float64) float64 {
+	q := a / b
+	if divError(a, b, q) > 0 {
+		return math.Nextafter(q, math.Inf(1))
+	}
+	return q
+}
+
+func sqrtDown(x float64) float64 {
+	s := math.Sqrt(x)
+	if !math.IsInf(s, 0) && math.FMA(-s, s, x) < 0 {
+		return math.Nextafter(s, math.Inf(-1))
+	}
+	return s
+}
+
+func sqrtUp(x float64) float64 {
+	s := math.Sqrt(x)
+	if !math.IsInf(s, 0) && math.FMA(-s, s, x) > 0 {
+		return math.Nextafter(s, math.Inf(1))
+	}
+	return s
+}
@@ -0,0 +1,299 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package evaluator
+
+import (
+	"calculator/src/lexer"
+	"calculator/src/parser"
+	"calculator/src/units"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// QuantityValue is a value of the Float backend for expressions with units:
+// a float64 amount of a product of units, such as 2.5 km/h.
+type QuantityValue struct {
+	// Value is the amount, measured in Unit.
+	Value float64
+	// Unit is the unit of Value. It is empty for plain numbers.
+	Unit units.Product
+	// Rates are the exchange rates Value was computed with, nil when no
+	// currency was converted.
+	Rates *units.Rates
+}
+
+// String writes the amount and its unit, followed by the date of the exchange
+// rates when they were used, as in "110.25 EUR (rates of 2025-03-14)".
+func (v QuantityValue) String() string {
+	return v.Format(Formatter{})
+}
+
+// Format writes the amount with f, followed by the unit.
+func (v QuantityValue) Format(f Formatter) string {
+	text := f.Format(v.Value)
+	if len(v.Unit) > 0 {
+		text += " " + v.Unit.String()
+	}
+	if v.Rates != nil {
+		text += fmt.Sprintf(" (rates of %s)", v.Rates.Stamp())
+	}
+	return text
+}
+
+// Float64 returns the amount, measured in Unit.
+func (v QuantityValue) Float64() float64 {
+	return v.Value
+}
+
+// errDimensionlessExponent is returned for a power whose exponent has a unit,
+// as in "2 ^ (3 m)".
+var errDimensionlessExponent = errors.New("an exponent cannot have a unit")
+
+// quantityArithmetic implements Arithmetic over QuantityValue.
+// Amounts are computed exactly like the Float backend does. Sums, differences
+// and conversions need operands of the same dimension; products and quotients
+// combine the units of their operands, and are plain numbers once every
+// dimension cancels out, as in "5 km / 2 m".
+type quantityArithmetic struct {
+	env *parser.Env
+}
+
+// NewQuantityArithmetic creates the arithmetic the Float backend uses for
+// expressions with units, reading the angle mode from env.
+func NewQuantityArithmetic(env *parser.Env) Arithmetic[QuantityValue] {
+	return quantityArithmetic{env: env}
+}
+
+func (a quantityArithmetic) Literal(n parser.NumberExpr) (QuantityValue, error) {
+	x, err := n.Float64()
+	if err != nil {
+		return QuantityValue{}, err
+	}
+	return a.FromFloat(x)
+}
+
+func (a quantityArithmetic) FromFloat(x float64) (QuantityValue, error) {
+	return QuantityValue{Value: x}, nil
+}
+
+// Constant returns the physical constants with their SI unit, so that
+// "c * 2 s" is a length.
+func (a quantityArithmetic) Constant(name string) (QuantityValue, bool) {
+	constant, exists := parser.LookupConstant(name)
+	if !exists {
+		return QuantityValue{}, false
+	}
+	unit, err := units.Parse(constant.Unit)
+	if err != nil {
+		return QuantityValue{}, false
+	}
+	return QuantityValue{Value: constant.Value, Unit: unit}, true
+}
+
+func (a quantityArithmetic) Unit(name string) (QuantityValue, bool) {
+	unit, exists := units.Lookup(name)
+	if !exists {
+		return QuantityValue{}, false
+	}
+	return QuantityValue{Value: 1, Unit: units.Of(unit)}, true
+}
+
+func (a quantityArithmetic) Quantity(value QuantityValue, unit string) (QuantityValue, error) {
+	product, err := units.Parse(unit)
+	if err != nil {
+		return QuantityValue{}, err
+	}
+	return exchanged(simplified(value.Value, value.Unit.Mul(product)), value), nil
+}
+
+func (a quantityArithmetic) Convert(value QuantityValue, unit string) (QuantityValue, error) {
+	product, err := units.Parse(unit)
+	if err != nil {
+		return QuantityValue{}, err
+	}
+	return convert(value, product)
+}
+
+func (a quantityArithmetic) Binary(op lexer.TokenKind, x, y QuantityValue) (QuantityValue, error) {
+	switch op {
+	case lexer.PLUS, lexer.DASH, lexer.PERCENT:
+		if !units.Convertible(x.Unit, y.Unit) {
+			return QuantityValue{}, &units.IncompatibleError{From: x.Unit, To: y.Unit}
+		}
+		y, _ = convert(y, x.Unit)
+		value, err := parser.BinaryOp(op, x.Value, y.Value)
+		return exchanged(QuantityValue{Value: value, Unit: x.Unit}, x, y), err
+	case lexer.STAR:
+		value, err := parser.BinaryOp(op, x.Value, y.Value)
+		return exchanged(simplified(value, x.Unit.Mul(y.Unit)), x, y), err
+	case lexer.SLASH:
+		value, err := parser.BinaryOp(op, x.Value, y.Value)
+		return exchanged(simplified(value, x.Unit.Mul(y.Unit.Inverse())), x, y), err
+	case lexer.HAT, lexer.ROOT:
+		if len(y.Unit) > 0 {
+			return QuantityValue{}, errDimensionlessExponent
+		}
+		exponent := y.Value
+		if op == lexer.ROOT {
+			exponent = 1 / y.Value
+		}
+		unit, ok := x.Unit.Pow(exponent)
+		if !ok {
+			return QuantityValue{}, fmt.Errorf("%s cannot be raised to the power %g", x.Unit, exponent)
+		}
+		value, err := parser.BinaryOp(op, x.Value, y.Value)
+		return exchanged(QuantityValue{Value: value, Unit: unit}, x), err
+	default:
+		if err := dimensionless(x, y); err != nil {
+			return QuantityValue{}, err
+		}
+		value, err := parser.BinaryOp(op, x.Value, y.Value)
+		return exchanged(QuantityValue{Value: value}, x, y), err
+	}
+}
+
+func (a quantityArithmetic) Unary(op lexer.TokenKind, x QuantityValue) (QuantityValue, error) {
+	value, err := parser.UnaryOp(op, x.Value)
+	return QuantityValue{Value: value, Unit: x.Unit, Rates: x.Rates}, err
+}
+
+func (a quantityArithmetic) Postfix(op lexer.TokenKind, x QuantityValue) (QuantityValue, error) {
+	if op != lexer.PERCENT {
+		if err := dimensionless(x); err != nil {
+			return QuantityValue{}, err
+		}
+	}
+	value, err := parser.PostfixOp(a.env, op, x.Value)
+	return QuantityValue{Value: value, Unit: x.Unit, Rates: x.Rates}, err
+}
+
+func (a quantityArithmetic) Call(name string, args []QuantityValue) (QuantityValue, error) {
+	values := make([]float64, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	converted := slices.Clone(args)
+
+	var unit units.Product
+	if len(args) > 0 && len(args[0].Unit) > 0 {
+		unit = args[0].Unit
+		switch name {
+		case "abs", "floor", "ceil", "round":
+			// The optional number of digits of round is a plain number.
+			if err := dimensionless(args[1:]...); err != nil {
+				return QuantityValue{}, err
+			}
+		case "min", "max":
+			for i, arg := range args {
+				var err error
+				converted[i], err = convert(arg, unit)
+				if err != nil {
+					return QuantityValue{}, err
+				}
+				values[i] = converted[i].Value
+			}
+		case "sqrt":
+			root, ok := unit.Pow(0.5)
+			if !ok {
+				return QuantityValue{}, fmt.Errorf("%s has no square root", unit)
+			}
+			unit = root
+		default:
+			return QuantityValue{}, fmt.Errorf("%s is not defined for quantities with units", name)
+		}
+	} else if err := dimensionless(args...); err != nil {
+		return QuantityValue{}, err
+	}
+
+	value, err := parser.CallFunction(a.env, name, values)
+	return exchanged(QuantityValue{Value: value, Unit: unit}, converted...), err
+}
+
+// convert returns x measured in unit, which must have the same dimension.
+func convert(x QuantityValue, unit units.Product) (QuantityValue, error) {
+	if !units.Convertible(x.Unit, unit) {
+		return QuantityValue{}, &units.IncompatibleError{From: x.Unit, To: unit}
+	}
+	result := QuantityValue{Value: x.Value, Unit: unit, Rates: x.Rates}
+	if from, to := x.Unit.Scale(), unit.Scale(); from != to {
+		result.Value = significant(x.Value * from / to)
+	}
+	if units.Exchanged(x.Unit, unit) {
+		result.Rates = units.CurrentRates()
+	}
+	return result, nil
+}
+
+// simplified returns value measured in unit with the units that divide each
+// other cancelled, as in km*min/h, or the plain number it is when the
+// dimensions of unit cancel out, as in km/m.
+func simplified(value float64, unit units.Product) QuantityValue {
+	cancelled, scale := unit.Cancel()
+	if scale != 1 {
+		value = significant(value * scale)
+	}
+	result := QuantityValue{Value: value, Unit: cancelled}
+	if len(cancelled) > 0 && cancelled.IsDimensionless() {
+		result = QuantityValue{Value: significant(value * cancelled.Scale())}
+	}
+	if units.Exchanged(unit, result.Unit) {
+		result.Rates = units.CurrentRates()
+	}
+	return result
+}
+
+// exchanged returns result computed from operands, keeping the exchange rates
+// any of them was computed with.
+func exchanged(result QuantityValue, operands ...QuantityValue) QuantityValue {
+	for _, operand := range operands {
+		if result.Rates == nil {
+			result.Rates = operand.Rates
+		}
+	}
+	return result
+}
+
+// significant rounds x to 15 significant digits, which hides the error of
+// conversion factors that are not exact in binary, so that 12 in is 1 ft
+// rather than 0.9999999999999998 ft.
+func significant(x float64) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(x, 'g', 15, 64), 64)
+	return rounded
+}
+
+// dimensionless returns an error unless every value is a plain number.
+func dimensionless(values ...QuantityValue) error {
+	for _, value := range values {
+		if len(value.Unit) > 0 {
+			return fmt.Errorf("expected a number without units but had %s", value)
+		}
+	}
+	return nil
+}
+
+// usesUnits reports whether expr involves units: a quantity, a conversion,
+// a unit name or a variable holding a quantity. The Float backend evaluates
+// only those expressions with quantityArithmetic.
+func usesUnits(expr parser.Expr, env *parser.Env) bool {
+	return anyNode(expr, func(expr parser.Expr) bool {
+		switch n := expr.(type) {
+		case parser.QuantityExpr, parser.ConversionExpr:
+			return true
+		case parser.IdentifierExpr:
+			if exact, exists := env.GetExact(n.Name); exists {
+				quantity, isQuantity := exact.(QuantityValue)
+				return isQuantity && len(quantity.Unit) > 0
+			}
+			if _, exists := env.Get(n.Name); exists {
+				return false
+			}
+			if _, exists := parser.LookupConstant(n.Name); exists {
+				return false
+			}
+			return units.IsUnit(n.Name)
+		}
+		return false
+	})
+}
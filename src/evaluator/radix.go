@@ -0,0 +1,176 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package evaluator
+
+import (
+	"calculator/src/parser"
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RadixValue is a Result shown in another base than 10, as asked for by a
+// conversion such as "255 to hex".
+type RadixValue struct {
+	// Value is the converted result.
+	Value Result
+	// Base is the base Value is shown in.
+	Base int
+}
+
+// String returns Value in Base, or as usual when it is not a real number.
+func (v RadixValue) String() string {
+	if text, ok := FormatRadix(v.Value, v.Base); ok {
+		return text
+	}
+	return v.Value.String()
+}
+func (v RadixValue) Float64() float64 {
+	return v.Value.Float64()
+}
+
+// errNotRadix is returned for a base conversion of a result that is not a
+// real number without a unit, as in "5 km to hex".
+var errNotRadix = errors.New("only a real number without a unit can be shown in another base")
+
+// radixPrefixes are the prefixes of the bases that have number literals, so
+// that results in them can be typed back in.
+var radixPrefixes = map[int]string{2: "0b", 8: "0o", 16: "0x"}
+
+// FormatRadix writes res in base, from parser.MinBase to parser.MaxBase, and
+// reports whether res is a real number without a unit that can be written so. Bases 2, 8 and
+// 16 get the prefix of their literals, as in "0xFF", and other bases a
+// suffix, as in "1331 (base 4)". Fractions are written with as many digits as
+// a float64 holds, so "0.1 to bin" is "0b0.0001100110011...".
+// Programmer integers are written in two's complement, as the keypad shows them.
+func FormatRadix(res Result, base int) (string, bool) {
+	if base < parser.MinBase || base > parser.MaxBase {
+		return "", false
+	}
+
+	var value *big.Rat
+	switch v := res.(type) {
+	case RadixValue:
+		return FormatRadix(v.Value, base)
+	case IntegerValue:
+		if base == 10 && !v.Unsigned {
+			value = new(big.Rat).SetInt64(v.Int64())
+		} else {
+			value = new(big.Rat).SetUint64(v.Bits)
+		}
+	case BigValue:
+		if v.Int != nil {
+			value = new(big.Rat).SetInt(v.Int)
+		} else if !v.Float.IsInf() {
+			value, _ = v.Float.Rat(nil)
+		}
+	case RatValue:
+		if v.Exact() {
+			value = v.Rat
+		} else {
+			value = ratFromFloat(v.Approx)
+		}
+	case DecimalValue:
+		value = new(big.Rat).SetFrac(v.Coef, pow10(v.Scale))
+	case FloatValue:
+		value = ratFromFloat(float64(v))
+	case ComplexValue:
+		if imag(v) == 0 {
+			value = ratFromFloat(real(v))
+		}
+	case QuantityValue:
+		if len(v.Unit) == 0 {
+			value = ratFromFloat(v.Value)
+		}
+	}
+	if value == nil {
+		return "", false
+	}
+
+	text := radixText(value, base)
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+	if prefix, ok := radixPrefixes[base]; ok {
+		return sign + prefix + text, true
+	}
+	if base == 10 {
+		return sign + text, true
+	}
+	return sign + text + " (base " + strconv.Itoa(base) + ")", true
+}
+
+// ratFromFloat returns x as an exact rational, or nil when x is not finite.
+func ratFromFloat(x float64) *big.Rat {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return nil
+	}
+	return new(big.Rat).SetFloat64(x)
+}
+
+// radixText writes r in base with uppercase letters for the digits above 9.
+// The fraction is rounded to the digits that a float64 can tell apart, so
+// that the float64 closest to 1/3 is "0.1" in base 3, and its trailing zeros
+// are dropped.
+func radixText(r *big.Rat, base int) string {
+	negative := r.Sign() < 0
+	abs := new(big.Rat).Abs(r)
+
+	whole, rest := new(big.Int).QuoRem(abs.Num(), abs.Denom(), new(big.Int))
+	var fraction []int
+	if rest.Sign() != 0 {
+		b := big.NewInt(int64(base))
+		digit := new(big.Int)
+		for range int(math.Ceil(53 / math.Log2(float64(base)))) {
+			digit.QuoRem(rest.Mul(rest, b), abs.Denom(), rest)
+			fraction = append(fraction, int(digit.Int64()))
+		}
+		// Round on one more digit, which drops the error of the float64
+		// the value often comes from, carrying into the whole part when
+		// every digit overflows.
+		if digit.QuoRem(rest.Mul(rest, b), abs.Denom(), rest); 2*digit.Int64() >= int64(base) {
+			i := len(fraction) - 1
+			for ; i >= 0 && fraction[i] == base-1; i-- {
+				fraction[i] = 0
+			}
+			if i >= 0 {
+				fraction[i]++
+			} else {
+				whole.Add(whole, big.NewInt(1))
+			}
+		}
+		for len(fraction) > 0 && fraction[len(fraction)-1] == 0 {
+			fraction = fraction[:len(fraction)-1]
+		}
+	}
+
+	text := strings.ToUpper(whole.Text(base))
+	if len(fraction) > 0 {
+		var digits strings.Builder
+		for _, digit := range fraction {
+			digits.WriteString(strings.ToUpper(strconv.FormatInt(int64(digit), base)))
+		}
+		text += "." + digits.String()
+	}
+
+	if negative && text != "0" {
+		return "-" + text
+	}
+	return text
+}
+
+// radixOf returns the base expr asks its result to be shown in, or 0 when it
+// does not. The conversion can be the whole expression or the value of an
+// assignment, as in "x = 255 to hex".
+func radixOf(expr parser.Expr) int {
+	switch n := expr.(type) {
+	case parser.RadixExpr:
+		return n.Base
+	case parser.AssignmentExpr:
+		return radixOf(n.Value)
+	}
+	return 0
+}
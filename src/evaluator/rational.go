@@ -0,0 +1,374 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package evaluator
+
+import (
+	"calculator/src/lexer"
+	"calculator/src/parser"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// FractionFormat controls how an exact rational result is displayed.
+type FractionFormat int
+
+const (
+	// Fraction shows an improper fraction such as "3/2".
+	Fraction FractionFormat = iota
+	// Mixed shows a whole part and a proper fraction such as "1 1/2".
+	Mixed
+	// DecimalNotation shows the value as a decimal number such as "1.5".
+	DecimalNotation
+)
+
+// FractionFormats lists every FractionFormat in the order the GUI cycles through them.
+var FractionFormats = []FractionFormat{Fraction, Mixed, DecimalNotation}
+
+// String returns the short indicator shown for the format.
+func (f FractionFormat) String() string {
+	switch f {
+	case Fraction:
+		return "a/b"
+	case Mixed:
+		return "n a/b"
+	case DecimalNotation:
+		return "0.5"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", int(f))
+	}
+}
+
+// ParseFractionFormat returns the FractionFormat whose indicator is s.
+func ParseFractionFormat(s string) (FractionFormat, error) {
+	for _, format := range FractionFormats {
+		if format.String() == s {
+			return format, nil
+		}
+	}
+	return Fraction, fmt.Errorf("unknown fraction format %q", s)
+}
+
+// Next returns the format that follows f in FractionFormats.
+func (f FractionFormat) Next() FractionFormat {
+	for i, format := range FractionFormats {
+		if format == f {
+			return FractionFormats[(i+1)%len(FractionFormats)]
+		}
+	}
+	return FractionFormats[0]
+}
+
+// RatValue is a value of the Rational backend. It is exact while only
+// operations with rational results are applied, and a float64 approximation
+// once an irrational one such as sqrt(2) or sin is involved.
+type RatValue struct {
+	// Rat is the exact value, or nil when the value is approximate.
+	Rat *big.Rat
+	// Approx is the value when Rat is nil.
+	Approx float64
+	// digits is the number of significant digits shown in DecimalNotation format.
+	digits uint
+}
+
+// Exact reports whether the value is an exact rational.
+func (v RatValue) Exact() bool {
+	return v.Rat != nil
+}
+
+func (v RatValue) String() string {
+	return v.Format(Fraction)
+}
+func (v RatValue) Float64() float64 {
+	if v.Rat == nil {
+		return v.Approx
+	}
+	value, _ := v.Rat.Float64()
+	return value
+}
+
+// Format returns the value displayed in format f.
+// Approximate values are always shown as decimals.
+func (v RatValue) Format(f FractionFormat) string {
+	if v.Rat == nil {
+		return fmt.Sprintf("%g", v.Approx)
+	}
+	if v.Rat.IsInt() {
+		return v.Rat.Num().String()
+	}
+
+	switch f {
+	case Mixed:
+		whole, remainder := new(big.Int).QuoRem(v.Rat.Num(), v.Rat.Denom(), new(big.Int))
+		if whole.Sign() == 0 {
+			return v.Rat.String()
+		}
+		return fmt.Sprintf("%s %s/%s", whole, remainder.Abs(remainder), v.Rat.Denom())
+	case DecimalNotation:
+		prec := uint(math.Ceil(float64(v.digits+5) * math.Log2(10)))
+		return new(big.Float).SetPrec(prec).SetRat(v.Rat).Text('g', int(v.digits))
+	default:
+		return v.Rat.String()
+	}
+}
+
+// errDivisionByZero is returned when an exact division has a zero divisor.
+var errDivisionByZero = errors.New("division by zero")
+
+// ratArithmetic implements Arithmetic over RatValue.
+type ratArithmetic struct {
+	digits uint
+	env    *parser.Env
+}
+
+// NewRatArithmetic creates the Rational backend arithmetic. digits is the
+// number of significant digits shown when a result is displayed as a decimal.
+func NewRatArithmetic(digits uint, env *parser.Env) Arithmetic[RatValue] {
+	if digits == 0 {
+		digits = DefaultPrecision
+	}
+	return ratArithmetic{digits: digits, env: env}
+}
+
+func (a ratArithmetic) exact(r *big.Rat) RatValue {
+	return RatValue{Rat: r, digits: a.digits}
+}
+func (a ratArithmetic) fromInt(i *big.Int) RatValue {
+	return a.exact(new(big.Rat).SetInt(i))
+}
+
+func (a ratArithmetic) Literal(n parser.NumberExpr) (RatValue, error) {
+	if n.Literal == "" {
+		return a.FromFloat(n.Value)
+	}
+
+	digits, base := parser.SplitNumber(n.Literal)
+	if base != 10 {
+		i, ok := new(big.Int).SetString(digits, base)
+		if !ok {
+			return RatValue{}, errors.New("invalid number " + n.Literal)
+		}
+		return a.fromInt(i), nil
+	}
+
+	r, ok := new(big.Rat).SetString(digits)
+	if !ok {
+		return RatValue{}, errors.New("invalid number " + n.Literal)
+	}
+	return a.exact(r), nil
+}
+
+// FromFloat converts x exactly when it is an integer. Other float64 values,
+// such as constants, are already approximations and stay approximate.
+func (a ratArithmetic) FromFloat(x float64) (RatValue, error) {
+	if math.IsNaN(x) {
+		return RatValue{}, errNotReal
+	}
+	if x == math.Trunc(x) && math.Abs(x) <= 1<<53 {
+		return a.exact(new(big.Rat).SetInt64(int64(x))), nil
+	}
+	return RatValue{Approx: x, digits: a.digits}, nil
+}
+
+func (a ratArithmetic) Binary(op lexer.TokenKind, x, y RatValue) (RatValue, error) {
+	if x.Exact() && y.Exact() {
+		if result, exact, err := ratBinary(op, x.Rat, y.Rat); err != nil || exact {
+			return a.exact(result), err
+		}
+	}
+
+	return a.approximate(func(args []float64) (float64, error) {
+		return parser.BinaryOp(op, args[0], args[1])
+	}, x, y)
+}
+
+func (a ratArithmetic) Unary(op lexer.TokenKind, x RatValue) (RatValue, error) {
+	if op == lexer.DASH && x.Exact() {
+		return a.exact(new(big.Rat).Neg(x.Rat)), nil
+	}
+
+	return a.approximate(func(args []float64) (float64, error) {
+		return parser.UnaryOp(op, args[0])
+	}, x)
+}
+
+func (a ratArithmetic) Postfix(op lexer.TokenKind, x RatValue) (RatValue, error) {
+	if x.Exact() {
+		switch op {
+		case lexer.BANG:
+			if x.Rat.IsInt() && x.Rat.Sign() >= 0 && x.Rat.Num().IsInt64() && x.Rat.Num().Int64() <= maxExactBits/32 {
+				return a.fromInt(new(big.Int).MulRange(1, x.Rat.Num().Int64())), nil
+			}
+		case lexer.PERCENT:
+			return a.exact(new(big.Rat).Quo(x.Rat, big.NewRat(100, 1))), nil
+		case lexer.DEGREE:
+			if a.env.Angle == parser.Degrees {
+				return x, nil
+			}
+		}
+	}
+
+	return a.approximate(func(args []float64) (float64, error) {
+		return parser.PostfixOp(a.env, op, args[0])
+	}, x)
+}
+
+func (a ratArithmetic) Call(name string, args []RatValue) (RatValue, error) {
+	if exactArgs(args) {
+		switch name {
+		case "abs":
+			return a.exact(new(big.Rat).Abs(args[0].Rat)), nil
+		case "sqrt":
+			if root, ok := ratSqrt(args[0].Rat); ok {
+				return a.exact(root), nil
+			}
+		case "floor", "ceil", "round":
+			if len(args) == 1 {
+				return a.fromInt(ratRound(name, args[0].Rat)), nil
+			}
+			// Like the float64 builtin, the number of decimal places is truncated.
+			places := new(big.Int).Quo(args[1].Rat.Num(), args[1].Rat.Denom())
+			if places.IsInt64() && places.CmpAbs(big.NewInt(maxExactBits)) < 0 {
+				factor := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), new(big.Int).Abs(places), nil))
+				if places.Sign() < 0 {
+					factor.Inv(factor)
+				}
+				scaled := ratRound("round", new(big.Rat).Mul(args[0].Rat, factor))
+				return a.exact(new(big.Rat).Quo(new(big.Rat).SetInt(scaled), factor)), nil
+			}
+		case "min", "max":
+			result := args[0]
+			for _, arg := range args[1:] {
+				if c := arg.Rat.Cmp(result.Rat); (name == "min" && c < 0) || (name == "max" && c > 0) {
+					result = arg
+				}
+			}
+			return result, nil
+		}
+	}
+
+	return a.approximate(func(floats []float64) (float64, error) {
+		return parser.CallFunction(a.env, name, floats)
+	}, args...)
+}
+
+// approximate computes fn over the float64 approximations of args, giving an
+// approximate result.
+func (a ratArithmetic) approximate(fn func(args []float64) (float64, error), args ...RatValue) (RatValue, error) {
+	floats := make([]float64, len(args))
+	for i, arg := range args {
+		floats[i] = arg.Float64()
+	}
+
+	result, err := fn(floats)
+	if err != nil {
+		return RatValue{}, err
+	}
+	if math.IsNaN(result) {
+		return RatValue{}, errNotReal
+	}
+	return RatValue{Approx: result, digits: a.digits}, nil
+}
+
+func exactArgs(args []RatValue) bool {
+	for _, arg := range args {
+		if !arg.Exact() {
+			return false
+		}
+	}
+	return true
+}
+
+// ratBinary applies op to two rationals, reporting whether the result is exact.
+func ratBinary(op lexer.TokenKind, x, y *big.Rat) (*big.Rat, bool, error) {
+	switch op {
+	case lexer.PLUS:
+		return new(big.Rat).Add(x, y), true, nil
+	case lexer.DASH:
+		return new(big.Rat).Sub(x, y), true, nil
+	case lexer.STAR:
+		return new(big.Rat).Mul(x, y), true, nil
+	case lexer.SLASH:
+		if y.Sign() == 0 {
+			return nil, false, errDivisionByZero
+		}
+		return new(big.Rat).Quo(x, y), true, nil
+	case lexer.PERCENT:
+		if y.Sign() == 0 {
+			return nil, false, errDivisionByZero
+		}
+		// x - n*y with n the nearest integer to x/y, ties to even, like math.Remainder.
+		n := ratRoundEven(new(big.Rat).Quo(x, y))
+		return new(big.Rat).Sub(x, new(big.Rat).Mul(new(big.Rat).SetInt(n), y)), true, nil
+	case lexer.HAT:
+		if !y.IsInt() || !y.Num().IsInt64() {
+			return nil, false, nil
+		}
+		n := y.Num().Int64()
+		bits := int64(max(x.Num().BitLen(), x.Denom().BitLen()))
+		if limit := maxExactBits / bits; n > limit || n < -limit {
+			return nil, false, nil
+		}
+		if n < 0 && x.Sign() == 0 {
+			return nil, false, errDivisionByZero
+		}
+		e := big.NewInt(max(n, -n))
+		result := new(big.Rat).SetFrac(new(big.Int).Exp(x.Num(), e, nil), new(big.Int).Exp(x.Denom(), e, nil))
+		if n < 0 {
+			result.Inv(result)
+		}
+		return result, true, nil
+	case lexer.ROOT:
+		if y.Cmp(big.NewRat(2, 1)) == 0 {
+			root, ok := ratSqrt(x)
+			return root, ok, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// ratSqrt returns the square root of r when it is rational.
+func ratSqrt(r *big.Rat) (*big.Rat, bool) {
+	if r.Sign() < 0 {
+		return nil, false
+	}
+	num, den := new(big.Int).Sqrt(r.Num()), new(big.Int).Sqrt(r.Denom())
+	if new(big.Int).Mul(num, num).Cmp(r.Num()) != 0 || new(big.Int).Mul(den, den).Cmp(r.Denom()) != 0 {
+		return nil, false
+	}
+	return new(big.Rat).SetFrac(num, den), true
+}
+
+// ratRound applies floor, ceil or round (half away from zero) to r.
+func ratRound(name string, r *big.Rat) *big.Int {
+	// Denominators are always positive, so Euclidean division floors.
+	floor := new(big.Int).Div(r.Num(), r.Denom())
+	if r.IsInt() {
+		return floor
+	}
+
+	switch name {
+	case "ceil":
+		return floor.Add(floor, big.NewInt(1))
+	case "round":
+		half := new(big.Rat).Add(new(big.Rat).Abs(r), big.NewRat(1, 2))
+		rounded := new(big.Int).Div(half.Num(), half.Denom())
+		if r.Sign() < 0 {
+			rounded.Neg(rounded)
+		}
+		return rounded
+	default:
+		return floor
+	}
+}
+
+// ratRoundEven rounds r to the nearest integer, ties to even.
+func ratRoundEven(r *big.Rat) *big.Int {
+	floor := new(big.Int).Div(r.Num(), r.Denom())
+	fraction := new(big.Rat).Sub(r, new(big.Rat).SetInt(floor))
+	if c := fraction.Cmp(big.NewRat(1, 2)); c > 0 || (c == 0 && floor.Bit(0) == 1) {
+		floor.Add(floor, big.NewInt(1))
+	}
+	return floor
+}
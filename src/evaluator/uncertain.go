@@ -0,0 +1,266 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package evaluator
+
+import (
+	"calculator/src/lexer"
+	"calculator/src/parser"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync/atomic"
+)
+
+// UncertainValue is a value of the Uncertain backend: a measurement with a
+// standard uncertainty, propagated to first order.
+//
+// The uncertainty is kept as the contribution of every independent source of
+// error, such as each "± sigma" written in an expression, rather than as a
+// single number. Values computed from the same variable share its sources, so
+// x - x is exactly 0 and x * x is √2 times as uncertain as the product of two
+// independent measurements of x.
+type UncertainValue struct {
+	// Value is the best estimate.
+	Value float64
+	// terms maps each source of error to the change in Value caused by one
+	// standard deviation of that source.
+	terms map[uint64]float64
+}
+
+// uncertaintyDigits is the number of significant digits results show of their
+// uncertainty. The value is rounded to the same decimal place.
+const uncertaintyDigits = 2
+
+// String returns the value and its uncertainty rounded to matching decimal
+// places, such as "55.35 ± 0.76", or the value alone when it is exact.
+// Very large and very small values share an exponent: "(6.0221 ± 0.0012)e23".
+func (v UncertainValue) String() string {
+	sigma := v.Sigma()
+	if sigma == 0 || math.IsInf(sigma, 0) || math.IsInf(v.Value, 0) {
+		if sigma == 0 {
+			return fmt.Sprintf("%g", v.Value)
+		}
+		return fmt.Sprintf("%g ± %g", v.Value, sigma)
+	}
+
+	places := uncertaintyDigits - 1 - int(math.Floor(math.Log10(sigma)))
+	exponent := int(math.Floor(math.Log10(math.Max(math.Abs(v.Value), sigma))))
+	if exponent < -4 || exponent >= 15 {
+		scale := math.Pow(10, float64(exponent))
+		places = max(places+exponent, 0)
+		return fmt.Sprintf("(%.*f ± %.*f)e%d", places, v.Value/scale, places, sigma/scale, exponent)
+	}
+	if places < 0 {
+		unit := math.Pow(10, float64(-places))
+		return fmt.Sprintf("%.0f ± %.0f", math.Round(v.Value/unit)*unit, math.Round(sigma/unit)*unit)
+	}
+	return fmt.Sprintf("%.*f ± %.*f", places, v.Value, places, sigma)
+}
+
+// Float64 returns the best estimate.
+func (v UncertainValue) Float64() float64 {
+	return v.Value
+}
+
+// Sigma returns the combined standard uncertainty.
+func (v UncertainValue) Sigma() float64 {
+	var sum float64
+	for _, term := range v.terms {
+		sum += term * term
+	}
+	return math.Sqrt(sum)
+}
+
+// lastSource numbers the sources of error. It is shared by every evaluation
+// so that variables assigned in different calculations stay independent.
+var lastSource atomic.Uint64
+
+// errNotDifferentiable is returned when an uncertain value is passed through
+// a point where the derivative is infinite, such as sqrt(0 ± 1), which first
+// order propagation cannot handle.
+var errNotDifferentiable = errors.New("uncertainty cannot be propagated where the result is not differentiable")
+
+// uncertainArithmetic implements Arithmetic over UncertainValue.
+// Values are computed exactly like the Float backend does. Uncertainties are
+// multiplied by the partial derivatives of each operation: exact ones for the
+// arithmetic operators and central differences for every other function.
+type uncertainArithmetic struct {
+	env *parser.Env
+}
+
+// NewUncertainArithmetic creates the Uncertain backend arithmetic, reading the
+// angle mode from env.
+func NewUncertainArithmetic(env *parser.Env) Arithmetic[UncertainValue] {
+	return uncertainArithmetic{env: env}
+}
+
+func (a uncertainArithmetic) Literal(n parser.NumberExpr) (UncertainValue, error) {
+	x, err := n.Float64()
+	if err != nil {
+		return UncertainValue{}, err
+	}
+	return a.FromFloat(x)
+}
+
+func (a uncertainArithmetic) FromFloat(x float64) (UncertainValue, error) {
+	if math.IsNaN(x) {
+		return UncertainValue{}, errNotReal
+	}
+	return UncertainValue{Value: x}, nil
+}
+
+// PlusMinus adds a new independent source of error with standard deviation
+// tolerance to value.
+func (a uncertainArithmetic) PlusMinus(value, tolerance UncertainValue) (UncertainValue, error) {
+	return withSource(value, math.Abs(tolerance.Value)), nil
+}
+
+// Between returns the uniform distribution from lo to hi, whose mean is the
+// midpoint and whose standard deviation is the width divided by √12.
+func (a uncertainArithmetic) Between(lo, hi UncertainValue) (UncertainValue, error) {
+	if lo.Value > hi.Value {
+		return UncertainValue{}, fmt.Errorf("lower bound %g is above upper bound %g", lo.Value, hi.Value)
+	}
+	mid, err := propagated((lo.Value+hi.Value)/2, []UncertainValue{lo, hi}, []float64{0.5, 0.5})
+	if err != nil {
+		return UncertainValue{}, err
+	}
+	return withSource(mid, (hi.Value-lo.Value)/math.Sqrt(12)), nil
+}
+
+func (a uncertainArithmetic) Binary(op lexer.TokenKind, x, y UncertainValue) (UncertainValue, error) {
+	if op == lexer.SLASH && y.Value == 0 {
+		return UncertainValue{}, errDivisionByZero
+	}
+	value, err := parser.BinaryOp(op, x.Value, y.Value)
+	if err != nil {
+		return UncertainValue{}, err
+	}
+	args := []UncertainValue{x, y}
+
+	switch op {
+	case lexer.PLUS:
+		return propagated(value, args, []float64{1, 1})
+	case lexer.DASH:
+		return propagated(value, args, []float64{1, -1})
+	case lexer.STAR:
+		return propagated(value, args, []float64{y.Value, x.Value})
+	case lexer.SLASH:
+		return propagated(value, args, []float64{1 / y.Value, -x.Value / (y.Value * y.Value)})
+	case lexer.HAT:
+		// Only differentiate with respect to an uncertain exponent, since
+		// ln(x) is not real for the negative bases of powers such as (-2)^3.
+		var byExponent float64
+		if len(y.terms) > 0 {
+			byExponent = value * math.Log(x.Value)
+		}
+		return propagated(value, args, []float64{y.Value * math.Pow(x.Value, y.Value-1), byExponent})
+	default:
+		return propagate(func(v []float64) (float64, error) { return parser.BinaryOp(op, v[0], v[1]) }, args)
+	}
+}
+
+func (a uncertainArithmetic) Unary(op lexer.TokenKind, x UncertainValue) (UncertainValue, error) {
+	value, err := parser.UnaryOp(op, x.Value)
+	if err != nil {
+		return UncertainValue{}, err
+	}
+	return propagated(value, []UncertainValue{x}, []float64{-1})
+}
+
+func (a uncertainArithmetic) Postfix(op lexer.TokenKind, x UncertainValue) (UncertainValue, error) {
+	value, err := parser.PostfixOp(a.env, op, x.Value)
+	if err != nil {
+		return UncertainValue{}, err
+	}
+	if op == lexer.PERCENT || op == lexer.DEGREE {
+		// Both are a multiplication by a constant factor.
+		factor, _ := parser.PostfixOp(a.env, op, 1)
+		return propagated(value, []UncertainValue{x}, []float64{factor})
+	}
+	return propagate(func(v []float64) (float64, error) { return parser.PostfixOp(a.env, op, v[0]) }, []UncertainValue{x})
+}
+
+func (a uncertainArithmetic) Call(name string, args []UncertainValue) (UncertainValue, error) {
+	return propagate(func(v []float64) (float64, error) { return parser.CallFunction(a.env, name, v) }, args)
+}
+
+// withSource adds a new independent source of error with standard deviation
+// sigma to x.
+func withSource(x UncertainValue, sigma float64) UncertainValue {
+	if sigma == 0 {
+		return x
+	}
+	terms := make(map[uint64]float64, len(x.terms)+1)
+	for source, term := range x.terms {
+		terms[source] = term
+	}
+	terms[lastSource.Add(1)] = sigma
+	return UncertainValue{Value: x.Value, terms: terms}
+}
+
+// propagated returns value with the uncertainties of args carried through,
+// where partials holds the derivative of value with respect to each of args.
+func propagated(value float64, args []UncertainValue, partials []float64) (UncertainValue, error) {
+	if math.IsNaN(value) {
+		return UncertainValue{}, errNotReal
+	}
+
+	terms := make(map[uint64]float64)
+	for i, arg := range args {
+		if len(arg.terms) == 0 {
+			continue
+		}
+		if math.IsNaN(partials[i]) || math.IsInf(partials[i], 0) {
+			return UncertainValue{}, errNotDifferentiable
+		}
+		for source, term := range arg.terms {
+			terms[source] += partials[i] * term
+		}
+	}
+	for source, term := range terms {
+		if term == 0 {
+			delete(terms, source)
+		}
+	}
+	return UncertainValue{Value: value, terms: terms}, nil
+}
+
+// propagate evaluates f at the values of args and carries their uncertainties
+// through it, estimating the partial derivatives numerically.
+func propagate(f func([]float64) (float64, error), args []UncertainValue) (UncertainValue, error) {
+	at := make([]float64, len(args))
+	for i, arg := range args {
+		at[i] = arg.Value
+	}
+	value, err := f(at)
+	if err != nil {
+		return UncertainValue{}, err
+	}
+
+	partials := make([]float64, len(args))
+	for i, arg := range args {
+		if len(arg.terms) > 0 && !math.IsNaN(value) {
+			partials[i] = derivative(f, at, i)
+		}
+	}
+	return propagated(value, args, partials)
+}
+
+// derivative estimates the partial derivative of f at the point at with
+// respect to argument i by a central difference. It is NaN when f is not
+// defined on both sides of the point, such as sqrt at 0.
+func derivative(f func([]float64) (float64, error), at []float64, i int) float64 {
+	h := 1e-5 * math.Max(math.Abs(at[i]), 1)
+	shifted := slices.Clone(at)
+	evaluate := func(x float64) float64 {
+		shifted[i] = x
+		y, err := f(shifted)
+		if err != nil {
+			return math.NaN()
+		}
+		return y
+	}
+	return (evaluate(at[i]+h) - evaluate(at[i]-h)) / (2 * h)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package lexer
+
+import "fmt"
+
+// Locale selects the characters numbers are written with: the decimal mark,
+// the separator of groups of thousands and the separator of the arguments of
+// a function call, which must not collide with the decimal mark.
+type Locale int
+
+const (
+	// DecimalPoint writes "1,234.5" and separates arguments with ",".
+	DecimalPoint Locale = iota
+	// DecimalComma writes "1.234,5" and separates arguments with ";".
+	DecimalComma
+	// DecimalCommaSpace writes "1 234,5" and separates arguments with ";".
+	DecimalCommaSpace
+	// DecimalPointApostrophe writes "1'234.5" and separates arguments with ",".
+	DecimalPointApostrophe
+)
+
+// Locales lists every Locale in the order they are offered to the user.
+var Locales = []Locale{DecimalPoint, DecimalComma, DecimalCommaSpace, DecimalPointApostrophe}
+
+// String returns the number the locale is shown with, such as "1.234,5".
+func (l Locale) String() string {
+	switch l {
+	case DecimalPoint, DecimalComma, DecimalCommaSpace, DecimalPointApostrophe:
+		return "1" + string(l.Grouping()) + "234" + string(l.Decimal()) + "5"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", int(l))
+	}
+}
+
+// ParseLocale returns the Locale whose number is s.
+func ParseLocale(s string) (Locale, error) {
+	for _, locale := range Locales {
+		if locale.String() == s {
+			return locale, nil
+		}
+	}
+	return DecimalPoint, fmt.Errorf("unknown locale %q", s)
+}
+
+// Decimal returns the decimal mark of the locale.
+func (l Locale) Decimal() rune {
+	switch l {
+	case DecimalComma, DecimalCommaSpace:
+		return ','
+	default:
+		return '.'
+	}
+}
+
+// Grouping returns the separator of groups of thousands of the locale.
+func (l Locale) Grouping() rune {
+	switch l {
+	case DecimalComma:
+		return '.'
+	case DecimalCommaSpace:
+		return ' '
+	case DecimalPointApostrophe:
+		return '\''
+	default:
+		return ','
+	}
+}
+
+// Separator returns the separator of the arguments of a function call and of
+// the bounds of an interval.
+func (l Locale) Separator() rune {
+	if l.Decimal() == ',' {
+		return ';'
+	}
+	return ','
+}
+
+// GroupsInput reports whether numbers typed in the locale may separate their
+// groups of thousands. They may not when the separator is also the argument
+// separator, as "max(1,234)" would be ambiguous.
+func (l Locale) GroupsInput() bool {
+	return l.Grouping() != l.Separator()
+}
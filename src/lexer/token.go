@@ -10,10 +10,19 @@ type TokenKind int
 const (
 	END TokenKind = iota
 	NUMBER
+	IDENTIFIER
+	ASSIGNMENT
+
+	// Calendar
+	DATE
+	DURATION
 
 	// Parenteses
 	OPEN_PAREN
 	CLOSE_PAREN
+	OPEN_BRACKET
+	CLOSE_BRACKET
+	COMMA
 
 	//Maths
 	PLUS
@@ -24,6 +33,22 @@ const (
 	ROOT
 	HAT
 	LOG
+	BANG
+	DEGREE
+	PLUS_MINUS
+	SQUARE_ROOT
+	SUPERSCRIPT
+
+	// Bitwise
+	AMPERSAND
+	PIPE
+	XOR
+	TILDE
+	SHIFT_LEFT
+	SHIFT_RIGHT
+
+	// Keywords
+	TO
 )
 
 // TokenKindString returns the string representation of a TokenKind.
@@ -33,10 +58,24 @@ func TokenKindString(kind TokenKind) string {
 		return "END"
 	case NUMBER:
 		return "NUMBER"
+	case IDENTIFIER:
+		return "IDENTIFIER"
+	case ASSIGNMENT:
+		return "ASSIGNMENT"
+	case DATE:
+		return "DATE"
+	case DURATION:
+		return "DURATION"
 	case OPEN_PAREN:
 		return "OPEN_PAREN"
 	case CLOSE_PAREN:
 		return "CLOSE_PAREN"
+	case OPEN_BRACKET:
+		return "OPEN_BRACKET"
+	case CLOSE_BRACKET:
+		return "CLOSE_BRACKET"
+	case COMMA:
+		return "COMMA"
 	case PLUS:
 		return "PLUS"
 	case DASH:
@@ -53,15 +92,53 @@ func TokenKindString(kind TokenKind) string {
 		return "HAT"
 	case LOG:
 		return "LOG"
+	case BANG:
+		return "BANG"
+	case DEGREE:
+		return "DEGREE"
+	case PLUS_MINUS:
+		return "PLUS_MINUS"
+	case SQUARE_ROOT:
+		return "SQUARE_ROOT"
+	case SUPERSCRIPT:
+		return "SUPERSCRIPT"
+	case AMPERSAND:
+		return "AMPERSAND"
+	case PIPE:
+		return "PIPE"
+	case XOR:
+		return "XOR"
+	case TILDE:
+		return "TILDE"
+	case SHIFT_LEFT:
+		return "SHIFT_LEFT"
+	case SHIFT_RIGHT:
+		return "SHIFT_RIGHT"
+	case TO:
+		return "TO"
 	default:
 		return fmt.Sprintf("UNKNOWN(%d)", kind)
 	}
 }
 
-// Token represents a single token with a kind and value.
+// Token represents a single token with a kind, value and its position in the source.
+// Start and End are byte offsets into the original input, End being exclusive.
 type Token struct {
 	Kind  TokenKind
 	Value string
+	Start int
+	End   int
+}
+
+// Span is a half-open range [Start, End) of byte offsets into the source.
+type Span struct {
+	Start int
+	End   int
+}
+
+// Span returns the range of the source covered by the Token.
+func (t Token) Span() Span {
+	return Span{Start: t.Start, End: t.End}
 }
 
 // ToString returns a formatted string representation of the Token.
@@ -76,9 +153,9 @@ func (t Token) KindString() string {
 
 }
 
-func newToken(kind TokenKind, value string) *Token {
+func newToken(kind TokenKind, value string, start int) *Token {
 	return &Token{
-		Kind: kind, Value: value,
+		Kind: kind, Value: value, Start: start, End: start + len(value),
 	}
 }
 
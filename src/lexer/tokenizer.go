@@ -5,7 +5,10 @@ package lexer
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type regexPattern struct {
@@ -19,6 +22,7 @@ type lexer struct {
 	Tokens   []Token
 	source   string
 	pos      int
+	locale   Locale
 }
 
 func (l *lexer) advanceN(i int) {
@@ -37,13 +41,42 @@ func (lex *lexer) at_end() bool {
 	return lex.pos >= len(lex.source)
 }
 
+// UnknownCharacterError is returned by Tokenize when the source contains a
+// character that does not start any known token.
+type UnknownCharacterError struct {
+	// Char is the unrecognized character.
+	Char rune
+	// Offset is the byte offset of Char in the source.
+	Offset int
+}
+
+func (e *UnknownCharacterError) Error() string {
+	return fmt.Sprintf("unknown character '%c' at offset %d", e.Char, e.Offset)
+}
+
 // Tokenize takes a source equation as input and returns a slice of Tokens.
 // It processes the source string, identifies tokens based on predefined patterns,
-// and handles errors for unrecognized tokens.
-func Tokenize(source string) []Token {
-	lex := createNewLexer(strings.ReplaceAll(source, " ", ""))
+// and returns an *UnknownCharacterError for unrecognized characters.
+// Numbers are read with a decimal point and arguments separated by commas.
+func Tokenize(source string) ([]Token, error) {
+	return TokenizeLocale(source, DecimalPoint)
+}
+
+// TokenizeLocale works like Tokenize with numbers written as in locale, so
+// that with DecimalComma "max(1.234,5; 2)" has the arguments 1234.5 and 2.
+// The Value of NUMBER and DURATION tokens is rewritten with a decimal point
+// and without group separators, as Tokenize would read it, while their Start
+// and End still cover the text of the source.
+func TokenizeLocale(source string, locale Locale) ([]Token, error) {
+	lex := createNewLexer(source, locale)
 
 	for !lex.at_end() {
+		char, size := utf8.DecodeRuneInString(lex.remainder())
+		if unicode.IsSpace(char) {
+			lex.advanceN(size)
+			continue
+		}
+
 		matched := false
 
 		for _, pattern := range lex.patterns {
@@ -56,12 +89,12 @@ func Tokenize(source string) []Token {
 		}
 
 		if !matched {
-			panic(fmt.Sprintf("lexer error: unrecognized token near '%v'", lex.remainder()))
+			return nil, &UnknownCharacterError{Char: char, Offset: lex.pos}
 		}
 	}
 
-	lex.push(newToken(END, ";"))
-	return lex.Tokens
+	lex.Tokens = append(lex.Tokens, Token{Kind: END, Value: ";", Start: lex.pos, End: lex.pos})
+	return lex.Tokens, nil
 }
 func EquationIsValid(tokens []Token) bool {
 	parentesesCount := 0
@@ -76,7 +109,7 @@ func EquationIsValid(tokens []Token) bool {
 			return false
 		}
 
-		if !IsOneOf(t.Kind, []TokenKind{OPEN_PAREN, CLOSE_PAREN, NUMBER}) {
+		if !IsOneOf(t.Kind, []TokenKind{OPEN_PAREN, CLOSE_PAREN, NUMBER, IDENTIFIER, DATE, DURATION}) {
 			if !operator {
 				return false
 			}
@@ -89,36 +122,184 @@ func EquationIsValid(tokens []Token) bool {
 	return parentesesCount == 0
 }
 
-func createNewLexer(source string) *lexer {
+func createNewLexer(source string, locale Locale) *lexer {
+	decimal := regexp.QuoteMeta(string(locale.Decimal()))
+	number := `([0-9](_?[0-9])*(` + decimal + `([0-9](_?[0-9])*)?)?|` + decimal + `[0-9](_?[0-9])*)`
+	if locale.GroupsInput() {
+		// Grouped numbers are tried first, so that "1.234" is not cut at the
+		// first group with DecimalComma.
+		number = `([0-9]{1,3}(` + regexp.QuoteMeta(string(locale.Grouping())) + `[0-9]{3})+(` + decimal + `([0-9](_?[0-9])*)?)?|` + number + `)`
+	}
+	number += `([eE][+-]?[0-9]+)?`
+	if locale.GroupsInput() {
+		// The last group must end at a non-digit, so that "1 2345" is not
+		// read as "1 234" followed by "5". The regexp has no lookahead, so
+		// numberHandler only takes the "number" group of the match.
+		number = `(?P<number>` + number + `)([^0-9]|$)`
+	}
+	part := durationPart(decimal)
+
 	return &lexer{
 		patterns: []regexPattern{
-			{regexp.MustCompile(`[0-9]+(\.[0-9]+)?`), numberHandler},
+			{regexp.MustCompile(`0[xX][0-9a-fA-F](_?[0-9a-fA-F])*`), numberHandler},
+			{regexp.MustCompile(`0[bB][01](_?[01])*`), numberHandler},
+			{regexp.MustCompile(`0[oO][0-7](_?[0-7])*`), numberHandler},
+			{regexp.MustCompile(`[0-9]{4}-[0-9]{2}-[0-9]{2}([T ][0-9]{2}:[0-9]{2}(:[0-9]{2})?)?\b`), calendarHandler(DATE)},
+			{regexp.MustCompile(`(` + part + ` ?)+` + part + `\b|[0-9]+(` + decimal + `[0-9]+)?[dw]\b`), calendarHandler(DURATION)},
+			{regexp.MustCompile(number), numberHandler},
+			{regexp.MustCompile(`[a-zA-Z_][a-zA-Z0-9_]*`), identifierHandler},
+			{regexp.MustCompile(`=`), defaultHandler(ASSIGNMENT, "=")},
 			{regexp.MustCompile(`\(`), defaultHandler(OPEN_PAREN, "(")},
 			{regexp.MustCompile(`\)`), defaultHandler(CLOSE_PAREN, ")")},
+			{regexp.MustCompile(`\[`), defaultHandler(OPEN_BRACKET, "[")},
+			{regexp.MustCompile(`\]`), defaultHandler(CLOSE_BRACKET, "]")},
+			{regexp.MustCompile(regexp.QuoteMeta(string(locale.Separator()))), defaultHandler(COMMA, string(locale.Separator()))},
 			{regexp.MustCompile(`\+`), defaultHandler(PLUS, "+")},
 			{regexp.MustCompile(`-`), defaultHandler(DASH, "-")},
 			{regexp.MustCompile(`/`), defaultHandler(SLASH, "/")},
 			{regexp.MustCompile(`\*`), defaultHandler(STAR, "*")},
 			{regexp.MustCompile(`%`), defaultHandler(PERCENT, "%")},
 			{regexp.MustCompile(`\^`), defaultHandler(HAT, "^")},
-			{regexp.MustCompile(`r`), defaultHandler(ROOT, "r")},
-			{regexp.MustCompile(`l`), defaultHandler(LOG, "l")},
-			{regexp.MustCompile(`l`), defaultHandler(SLASH, "-")},
+			{regexp.MustCompile(`!`), defaultHandler(BANG, "!")},
+			{regexp.MustCompile(`°`), defaultHandler(DEGREE, "°")},
+			{regexp.MustCompile(`±`), defaultHandler(PLUS_MINUS, "±")},
+			{regexp.MustCompile(`<<`), defaultHandler(SHIFT_LEFT, "<<")},
+			{regexp.MustCompile(`>>`), defaultHandler(SHIFT_RIGHT, ">>")},
+			{regexp.MustCompile(`&`), defaultHandler(AMPERSAND, "&")},
+			{regexp.MustCompile(`\|`), defaultHandler(PIPE, "|")},
+			{regexp.MustCompile(`~`), defaultHandler(TILDE, "~")},
+			{regexp.MustCompile(`×|·`), aliasHandler(STAR, "*")},
+			{regexp.MustCompile(`÷`), aliasHandler(SLASH, "/")},
+			{regexp.MustCompile(`−`), aliasHandler(DASH, "-")},
+			{regexp.MustCompile(`π`), aliasHandler(IDENTIFIER, "pi")},
+			{regexp.MustCompile(`√`), defaultHandler(SQUARE_ROOT, "√")},
+			{regexp.MustCompile(SuperscriptMinus + `?[` + SuperscriptDigits + `]+`), superscriptHandler},
 		},
 		Tokens: make([]Token, 0),
 		source: source,
 		pos:    0,
+		locale: locale,
 	}
 }
 func defaultHandler(kind TokenKind, value string) regexHandler {
 	return func(lex *lexer, _ *regexp.Regexp) {
+		lex.push(newToken(kind, value, lex.pos))
 		lex.advanceN(len(value))
-		lex.push(newToken(kind, value))
 	}
 }
 
+// aliasHandler pushes a token for a symbol that stands for an ASCII one, as
+// "×" for "*" or "π" for the constant pi. The token has the value of the ASCII
+// symbol, so the parser and evaluators need not know about the alias, while
+// its Start and End cover the symbol in the source.
+func aliasHandler(kind TokenKind, value string) regexHandler {
+	return func(lex *lexer, regex *regexp.Regexp) {
+		match := regex.FindString(lex.remainder())
+		lex.push(&Token{Kind: kind, Value: value, Start: lex.pos, End: lex.pos + len(match)})
+		lex.advanceN(len(match))
+	}
+}
+
+// SuperscriptDigits are the superscript digits from 0 to 9, which write an
+// exponent as in "x²".
+const SuperscriptDigits = "⁰¹²³⁴⁵⁶⁷⁸⁹"
+
+// SuperscriptMinus is the superscript minus sign of negative exponents, as in
+// "10⁻³".
+const SuperscriptMinus = "⁻"
+
+// superscriptHandler pushes a SUPERSCRIPT token for an exponent written in
+// superscript, as the "²" of "x²" or the "⁻³" of "10⁻³". Its value is the
+// exponent in ASCII digits, with a leading "-" when it is negative.
+func superscriptHandler(lex *lexer, regex *regexp.Regexp) {
+	match := regex.FindString(lex.remainder())
+
+	superscripts := []rune(SuperscriptDigits)
+	var exponent strings.Builder
+	for _, r := range match {
+		if string(r) == SuperscriptMinus {
+			exponent.WriteByte('-')
+		} else {
+			exponent.WriteRune('0' + rune(slices.Index(superscripts, r)))
+		}
+	}
+	lex.push(&Token{Kind: SUPERSCRIPT, Value: exponent.String(), Start: lex.pos, End: lex.pos + len(match)})
+	lex.advanceN(len(match))
+}
+
+// numberHandler pushes a NUMBER token for a numeric literal. Literals may be decimal
+// with optional leading or trailing point and exponent ("1e-9", ".5", "5."),
+// hexadecimal, binary or octal with a 0x, 0b or 0o prefix, and may use "_"
+// between digits ("1_000_000"). Decimals are written with the decimal mark of
+// the locale, and with its group separators when it allows them ("1.234,5").
 func numberHandler(lex *lexer, regex *regexp.Regexp) {
 	match := regex.FindString(lex.remainder())
-	lex.push(newToken(NUMBER, match))
+	if group := regex.SubexpIndex("number"); group >= 0 {
+		match = regex.FindStringSubmatch(lex.remainder())[group]
+	}
+	value := match
+	if lex.locale.GroupsInput() {
+		value = strings.ReplaceAll(value, string(lex.locale.Grouping()), "")
+	}
+	lex.pushLocalized(NUMBER, value, match)
+}
+
+// pushLocalized pushes a token of the text match whose value is written with
+// the decimal mark of the locale, rewriting it with a decimal point.
+func (lex *lexer) pushLocalized(kind TokenKind, value string, match string) {
+	value = strings.ReplaceAll(value, string(lex.locale.Decimal()), ".")
+	lex.push(&Token{Kind: kind, Value: value, Start: lex.pos, End: lex.pos + len(match)})
+	lex.advanceN(len(match))
+}
+
+// durationPart is the pattern of one part of a duration literal: a number of
+// weeks, days, hours, minutes ("min" or "m") or seconds, as the "45m" of
+// "3h 45m", with the quoted decimal mark decimal.
+func durationPart(decimal string) string {
+	return `[0-9]+(` + decimal + `[0-9]+)?(w|d|h|min|m|s)`
+}
+
+// calendarHandler pushes a DATE or DURATION token for a literal such as
+// "2026-03-01", "2026-03-01T09:30" or "3h 45m". A duration is made of at
+// least two parts, optionally separated by a space, or is a single number of
+// days or weeks: "2h" alone stays a number of the unit h, and "45m" one of m.
+// Only the shape of a date is checked, so that "2026-13-01" is a DATE the
+// parser reports as invalid rather than a subtraction.
+func calendarHandler(kind TokenKind) regexHandler {
+	return func(lex *lexer, regex *regexp.Regexp) {
+		match := regex.FindString(lex.remainder())
+		lex.pushLocalized(kind, match, match)
+	}
+}
+
+// identifierHandler pushes an IDENTIFIER token for a name.
+// The root and logarithm operators are letters too, so a lone "r" or "l",
+// or one directly followed by digits as in "9r2" or "100l10", is pushed as
+// a ROOT or LOG operator instead and the digits are left for the next token.
+// The keyword "to" of unit conversions is pushed as a TO token, and "xor",
+// the exclusive or of integers since "^" is already the power, as a XOR token.
+func identifierHandler(lex *lexer, regex *regexp.Regexp) {
+	match := regex.FindString(lex.remainder())
+	switch match {
+	case "to":
+		defaultHandler(TO, match)(lex, regex)
+		return
+	case "xor":
+		defaultHandler(XOR, match)(lex, regex)
+		return
+	}
+
+	if match[0] == 'r' || match[0] == 'l' {
+		if strings.Trim(match[1:], "0123456789") == "" {
+			kind := ROOT
+			if match[0] == 'l' {
+				kind = LOG
+			}
+			defaultHandler(kind, match[:1])(lex, regex)
+			return
+		}
+	}
+
+	lex.push(newToken(IDENTIFIER, match, lex.pos))
 	lex.advanceN(len(match))
 }
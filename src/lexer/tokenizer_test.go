@@ -4,7 +4,9 @@ package lexer_test
 
 import (
 	"calculator/src/lexer"
+	"errors"
 	"fmt"
+	"slices"
 	"testing"
 )
 
@@ -24,6 +26,28 @@ var equations = []EquationResult{
 	{"4r7-2^5", 8},
 	{"45.2+81", 4},
 	{"42^(3+2)", 8},
+	{"rate = 0.07", 4},
+	{"total=price*rate", 6},
+	{"9r2+100l10", 8},
+	{"max(1, 2.5, x)", 9},
+	{"1e-9*6.02E23", 4},
+	{".5+5.", 4},
+	{"0x1F+0b1010-0o17", 6},
+	{"1_000_000", 2},
+	{"2e", 3},
+	{"5!+10%", 6},
+	{"sin(90°)", 6},
+	{"10±0.2", 4},
+	{"[9.8, 10.2]", 6},
+	{"5 km to mi", 5},
+	{"2026-03-01 + 90 days", 5},
+	{"2026-03-01T09:30 - today", 4},
+	{"3h 45m * 4", 4},
+	{"1d 12h", 2},
+	{"2w", 2},
+	{"0xF0 & 0x3C | 1", 6},
+	{"5 xor 3", 4},
+	{"~x << 2 >> 1", 7},
 	{"45.2++81", -1},
 	{"45.2+-81", -1},
 	{"+45.2+81", -1},
@@ -34,7 +58,11 @@ var equations = []EquationResult{
 
 func TestTokenizeEquations(t *testing.T) {
 	for i, res := range equations {
-		tokens := lexer.Tokenize(res.eq)
+		tokens, err := lexer.Tokenize(res.eq)
+		if err != nil {
+			t.Errorf("Unexpected error in %s: %v", res.eq, err)
+			continue
+		}
 		fmt.Printf("Equation %d: %s\n", i+1, res.eq)
 		for _, token := range tokens {
 			fmt.Printf("%s\n", token.ToString())
@@ -50,3 +78,215 @@ func TestTokenizeEquations(t *testing.T) {
 		fmt.Println()
 	}
 }
+
+func TestTokenizeUnknownCharacter(t *testing.T) {
+	_, err := lexer.Tokenize("12 + 3 $ 4")
+
+	var unknown *lexer.UnknownCharacterError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("Expected UnknownCharacterError but had %v", err)
+	}
+	if unknown.Char != '$' || unknown.Offset != 7 {
+		t.Errorf("Expected '$' at offset 7 but had '%c' at offset %d", unknown.Char, unknown.Offset)
+	}
+}
+
+func TestTokenizePositions(t *testing.T) {
+	source := " 12 +(3.5)"
+	expected := []lexer.Span{{Start: 1, End: 3}, {Start: 4, End: 5}, {Start: 5, End: 6}, {Start: 6, End: 9}, {Start: 9, End: 10}, {Start: 10, End: 10}}
+
+	tokens, err := lexer.Tokenize(source)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d Tokens but had %d", len(expected), len(tokens))
+	}
+	for i, token := range tokens {
+		if token.Span() != expected[i] {
+			t.Errorf("Token %s: expected span %v but had %v", token.ToString(), expected[i], token.Span())
+		}
+		if token.Kind != lexer.END && source[token.Start:token.End] != token.Value {
+			t.Errorf("Token %s does not match source %q", token.ToString(), source[token.Start:token.End])
+		}
+	}
+}
+
+func TestTokenizeCalendar(t *testing.T) {
+	cases := []struct {
+		source string
+		kinds  []lexer.TokenKind
+	}{
+		{"2026-03-01", []lexer.TokenKind{lexer.DATE}},
+		{"2026-03-01 09:30:15", []lexer.TokenKind{lexer.DATE}},
+		{"2026-13-01", []lexer.TokenKind{lexer.DATE}},
+		{"2026-03-01 25:00", []lexer.TokenKind{lexer.DATE}},
+		{"2026-3-1", []lexer.TokenKind{lexer.NUMBER, lexer.DASH, lexer.NUMBER, lexer.DASH, lexer.NUMBER}},
+		{"3h 45m", []lexer.TokenKind{lexer.DURATION}},
+		{"1h30min", []lexer.TokenKind{lexer.DURATION}},
+		{"2.5d", []lexer.TokenKind{lexer.DURATION}},
+		{"3h", []lexer.TokenKind{lexer.NUMBER, lexer.IDENTIFIER}},
+		{"45m", []lexer.TokenKind{lexer.NUMBER, lexer.IDENTIFIER}},
+		{"3h 45 m", []lexer.TokenKind{lexer.NUMBER, lexer.IDENTIFIER, lexer.NUMBER, lexer.IDENTIFIER}},
+		{"2days", []lexer.TokenKind{lexer.NUMBER, lexer.IDENTIFIER}},
+		{"5ms", []lexer.TokenKind{lexer.NUMBER, lexer.IDENTIFIER}},
+	}
+
+	for _, c := range cases {
+		tokens, err := lexer.Tokenize(c.source)
+		if err != nil {
+			t.Errorf("Unexpected error in %s: %v", c.source, err)
+			continue
+		}
+		kinds := make([]lexer.TokenKind, 0, len(tokens))
+		for _, token := range tokens[:len(tokens)-1] {
+			kinds = append(kinds, token.Kind)
+		}
+		if !slices.Equal(kinds, c.kinds) {
+			t.Errorf("In %s: expected the tokens %v but had %v", c.source, c.kinds, kinds)
+		}
+	}
+}
+
+func TestTokenizeBitwise(t *testing.T) {
+	cases := []struct {
+		source string
+		kinds  []lexer.TokenKind
+	}{
+		{"a&b|c", []lexer.TokenKind{lexer.IDENTIFIER, lexer.AMPERSAND, lexer.IDENTIFIER, lexer.PIPE, lexer.IDENTIFIER}},
+		{"5 xor 3", []lexer.TokenKind{lexer.NUMBER, lexer.XOR, lexer.NUMBER}},
+		{"xors", []lexer.TokenKind{lexer.IDENTIFIER}},
+		{"~0", []lexer.TokenKind{lexer.TILDE, lexer.NUMBER}},
+		{"1<<4>>2", []lexer.TokenKind{lexer.NUMBER, lexer.SHIFT_LEFT, lexer.NUMBER, lexer.SHIFT_RIGHT, lexer.NUMBER}},
+	}
+
+	for _, c := range cases {
+		tokens, err := lexer.Tokenize(c.source)
+		if err != nil {
+			t.Errorf("Unexpected error in %s: %v", c.source, err)
+			continue
+		}
+		kinds := make([]lexer.TokenKind, 0, len(tokens))
+		for _, token := range tokens[:len(tokens)-1] {
+			kinds = append(kinds, token.Kind)
+		}
+		if !slices.Equal(kinds, c.kinds) {
+			t.Errorf("In %s: expected the tokens %v but had %v", c.source, c.kinds, kinds)
+		}
+	}
+}
+
+func TestTokenizeLocale(t *testing.T) {
+	cases := []struct {
+		locale lexer.Locale
+		source string
+		values []string
+	}{
+		{lexer.DecimalPoint, "max(1.5, 2)", []string{"max", "(", "1.5", ",", "2", ")"}},
+		{lexer.DecimalComma, "max(1,5; 2)", []string{"max", "(", "1.5", ";", "2", ")"}},
+		{lexer.DecimalComma, "1.234,5 + 0,25", []string{"1234.5", "+", "0.25"}},
+		{lexer.DecimalComma, "1.234.567", []string{"1234567"}},
+		{lexer.DecimalComma, "1,5e3 * ,5", []string{"1.5e3", "*", ".5"}},
+		{lexer.DecimalComma, "2,5d", []string{"2.5d"}},
+		{lexer.DecimalCommaSpace, "1 234 567,5", []string{"1234567.5"}},
+		{lexer.DecimalCommaSpace, "1 2345", []string{"1", "2345"}},
+		{lexer.DecimalCommaSpace, "1 234 5678", []string{"1234", "5678"}},
+		{lexer.DecimalCommaSpace, "1 234km", []string{"1234", "km"}},
+		{lexer.DecimalCommaSpace, "1 234e3", []string{"1234e3"}},
+		{lexer.DecimalPointApostrophe, "min(1'234.5, 7)", []string{"min", "(", "1234.5", ",", "7", ")"}},
+		{lexer.DecimalPoint, "0x1F", []string{"0x1F"}},
+	}
+
+	for _, c := range cases {
+		tokens, err := lexer.TokenizeLocale(c.source, c.locale)
+		if err != nil {
+			t.Errorf("Unexpected error in %s: %v", c.source, err)
+			continue
+		}
+		values := make([]string, 0, len(tokens))
+		for _, token := range tokens[:len(tokens)-1] {
+			values = append(values, token.Value)
+		}
+		if !slices.Equal(values, c.values) {
+			t.Errorf("In %s with %v: expected the tokens %q but had %q", c.source, c.locale, c.values, values)
+		}
+	}
+}
+
+func TestTokenizeLocaleSpans(t *testing.T) {
+	tokens, err := lexer.TokenizeLocale("1.234,5+2", lexer.DecimalComma)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if tokens[0].Span() != (lexer.Span{Start: 0, End: 7}) || tokens[1].Start != 7 {
+		t.Errorf("Expected the number to span the source text but had %v", tokens[0].Span())
+	}
+
+	// A point that does not separate groups of three is not a number with a
+	// decimal comma.
+	_, err = lexer.TokenizeLocale("12.34", lexer.DecimalComma)
+	var unknown *lexer.UnknownCharacterError
+	if !errors.As(err, &unknown) || unknown.Offset != 2 {
+		t.Errorf("Expected an UnknownCharacterError at offset 2 but had %v", err)
+	}
+
+	// Nor is one followed by more than three digits.
+	_, err = lexer.TokenizeLocale("1.2345", lexer.DecimalComma)
+	if !errors.As(err, &unknown) || unknown.Offset != 1 {
+		t.Errorf("Expected an UnknownCharacterError at offset 1 but had %v", err)
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	for _, locale := range lexer.Locales {
+		parsed, err := lexer.ParseLocale(locale.String())
+		if err != nil || parsed != locale {
+			t.Errorf("Expected %v to round trip but had %v, %v", locale, parsed, err)
+		}
+	}
+	if lexer.DecimalComma.String() != "1.234,5" || lexer.DecimalComma.Separator() != ';' {
+		t.Errorf("Unexpected characters of %v", lexer.DecimalComma)
+	}
+}
+
+func TestTokenizeUnicode(t *testing.T) {
+	cases := []struct {
+		source string
+		kinds  []lexer.TokenKind
+		values []string
+	}{
+		{"3×4÷2−1", []lexer.TokenKind{lexer.NUMBER, lexer.STAR, lexer.NUMBER, lexer.SLASH, lexer.NUMBER, lexer.DASH, lexer.NUMBER}, []string{"3", "*", "4", "/", "2", "-", "1"}},
+		{"2·π", []lexer.TokenKind{lexer.NUMBER, lexer.STAR, lexer.IDENTIFIER}, []string{"2", "*", "pi"}},
+		{"√9", []lexer.TokenKind{lexer.SQUARE_ROOT, lexer.NUMBER}, []string{"√", "9"}},
+		{"x²+y³", []lexer.TokenKind{lexer.IDENTIFIER, lexer.SUPERSCRIPT, lexer.PLUS, lexer.IDENTIFIER, lexer.SUPERSCRIPT}, []string{"x", "2", "+", "y", "3"}},
+		{"10⁻¹²", []lexer.TokenKind{lexer.NUMBER, lexer.SUPERSCRIPT}, []string{"10", "-12"}},
+		{"2⁰⁴⁵⁶⁷⁸⁹", []lexer.TokenKind{lexer.NUMBER, lexer.SUPERSCRIPT}, []string{"2", "0456789"}},
+	}
+
+	for _, c := range cases {
+		tokens, err := lexer.Tokenize(c.source)
+		if err != nil {
+			t.Errorf("Unexpected error in %s: %v", c.source, err)
+			continue
+		}
+		kinds := make([]lexer.TokenKind, 0, len(tokens))
+		values := make([]string, 0, len(tokens))
+		for _, token := range tokens[:len(tokens)-1] {
+			kinds = append(kinds, token.Kind)
+			values = append(values, token.Value)
+		}
+		if !slices.Equal(kinds, c.kinds) || !slices.Equal(values, c.values) {
+			t.Errorf("In %s: expected the tokens %v %q but had %v %q", c.source, c.kinds, c.values, kinds, values)
+		}
+	}
+
+	// Aliases keep the span of the symbol in the source.
+	source := "1 × 2²"
+	tokens, _ := lexer.Tokenize(source)
+	if got := source[tokens[1].Start:tokens[1].End]; got != "×" {
+		t.Errorf("Expected the operator to span × but had %q", got)
+	}
+	if got := source[tokens[3].Start:tokens[3].End]; got != "²" {
+		t.Errorf("Expected the exponent to span ² but had %q", got)
+	}
+}
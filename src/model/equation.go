@@ -28,6 +28,7 @@ const (
 // Equation represents a mathematical equation as a string.
 type Equation struct {
 	Equation string // The equation string to be parsed and evaluated.
+	Base     int    // The base the result was shown in, or 0 before it was computed.
 }
 
 // ParseEquation parses the input equation into individual components (numbers and operators) based on a given toIgnore.
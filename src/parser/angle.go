@@ -0,0 +1,100 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package parser
+
+import (
+	"fmt"
+	"math"
+)
+
+// AngleMode controls how trigonometric functions interpret angles.
+type AngleMode int
+
+const (
+	Radians AngleMode = iota
+	Degrees
+	Gradians
+)
+
+// AngleModes lists every AngleMode in the order the GUI cycles through them.
+var AngleModes = []AngleMode{Degrees, Radians, Gradians}
+
+// String returns the short indicator shown for the mode, such as "DEG".
+func (m AngleMode) String() string {
+	switch m {
+	case Radians:
+		return "RAD"
+	case Degrees:
+		return "DEG"
+	case Gradians:
+		return "GRAD"
+	default:
+		return fmt.Sprintf("UNKNOWN(%d)", int(m))
+	}
+}
+
+// ParseAngleMode returns the AngleMode whose indicator is s.
+func ParseAngleMode(s string) (AngleMode, error) {
+	for _, mode := range AngleModes {
+		if mode.String() == s {
+			return mode, nil
+		}
+	}
+	return Radians, fmt.Errorf("unknown angle mode %q", s)
+}
+
+// Next returns the mode that follows m in AngleModes.
+func (m AngleMode) Next() AngleMode {
+	for i, mode := range AngleModes {
+		if mode == m {
+			return AngleModes[(i+1)%len(AngleModes)]
+		}
+	}
+	return AngleModes[0]
+}
+
+// ToRadians converts an angle expressed in mode m to radians.
+func (m AngleMode) ToRadians(angle float64) float64 {
+	switch m {
+	case Degrees:
+		return angle * math.Pi / 180
+	case Gradians:
+		return angle * math.Pi / 200
+	default:
+		return angle
+	}
+}
+
+// FromRadians converts an angle in radians to mode m.
+func (m AngleMode) FromRadians(angle float64) float64 {
+	switch m {
+	case Degrees:
+		return angle * 180 / math.Pi
+	case Gradians:
+		return angle * 200 / math.Pi
+	default:
+		return angle
+	}
+}
+
+// quarterTurns reports how many quarter turns, reduced to 0..3, the angle
+// represents when it is an exact multiple of one in a non-radian mode.
+// Trigonometric functions use it to return exact results such as sin(180) = 0
+// in degrees, which the conversion through pi would otherwise miss.
+func (m AngleMode) quarterTurns(angle float64) (int, bool) {
+	var quarter float64
+	switch m {
+	case Degrees:
+		quarter = 90
+	case Gradians:
+		quarter = 100
+	default:
+		return 0, false
+	}
+
+	turns := angle / quarter
+	if turns != math.Trunc(turns) || math.IsInf(turns, 0) {
+		return 0, false
+	}
+	return int(math.Mod(math.Mod(turns, 4)+4, 4)), true
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package parser
+
+import (
+	"calculator/src/lexer"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// errNotInteger is returned when a bitwise operator is applied to a number
+// that is not an integer of at most 64 bits.
+var errNotInteger = errors.New("bitwise operators need integers of at most 64 bits")
+
+// errNegativeShift is returned by a shift by a negative number of bits.
+var errNegativeShift = errors.New("cannot shift by a negative number of bits")
+
+// bitwiseOp applies the bitwise operator kind to a and b, which must be
+// integers. In float64 arithmetic they are 64-bit two's complement integers,
+// so ">>" keeps the sign.
+func bitwiseOp(kind lexer.TokenKind, a, b float64) (float64, error) {
+	x, err := toInt64(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := toInt64(b)
+	if err != nil {
+		return 0, err
+	}
+
+	switch kind {
+	case lexer.AMPERSAND:
+		return float64(x & y), nil
+	case lexer.PIPE:
+		return float64(x | y), nil
+	case lexer.XOR:
+		return float64(x ^ y), nil
+	case lexer.SHIFT_LEFT:
+		if y < 0 {
+			return 0, errNegativeShift
+		}
+		return float64(x << y), nil
+	case lexer.SHIFT_RIGHT:
+		if y < 0 {
+			return 0, errNegativeShift
+		}
+		return float64(x >> y), nil
+	default:
+		return 0, fmt.Errorf("operator %s not recognized", lexer.TokenKindString(kind))
+	}
+}
+
+// toInt64 converts a to an int64 when it is an integer in its range.
+func toInt64(a float64) (int64, error) {
+	if a != math.Trunc(a) || a < math.MinInt64 || a >= math.MaxInt64 {
+		return 0, errNotInteger
+	}
+	return int64(a), nil
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package parser
+
+import (
+	"maps"
+	"math"
+	"slices"
+)
+
+// variadic marks a builtin that accepts any number of arguments above its minimum.
+const variadic = -1
+
+// builtin describes a function that can be called from an expression.
+type builtin struct {
+	// minArgs and maxArgs bound the number of accepted arguments.
+	// maxArgs is variadic when there is no upper bound.
+	minArgs int
+	maxArgs int
+	// fn computes the result. It is only called with an accepted number of arguments.
+	fn func(env *Env, args []float64) float64
+}
+
+// accepts reports whether the builtin can be called with n arguments.
+func (b builtin) accepts(n int) bool {
+	return n >= b.minArgs && (b.maxArgs == variadic || n <= b.maxArgs)
+}
+
+// builtins is the registry of functions available to CallExpr, keyed by name.
+var builtins = map[string]builtin{
+	"sin":   angle_builtin(math.Sin, [4]float64{0, 1, 0, -1}),
+	"cos":   angle_builtin(math.Cos, [4]float64{1, 0, -1, 0}),
+	"tan":   angle_builtin(math.Tan, [4]float64{0, math.Inf(1), 0, math.Inf(-1)}),
+	"asin":  inverse_angle_builtin(math.Asin),
+	"acos":  inverse_angle_builtin(math.Acos),
+	"atan":  inverse_angle_builtin(math.Atan),
+	"sqrt":  unary_builtin(math.Sqrt),
+	"abs":   unary_builtin(math.Abs),
+	"ln":    unary_builtin(math.Log),
+	"exp":   unary_builtin(math.Exp),
+	"floor": unary_builtin(math.Floor),
+	"ceil":  unary_builtin(math.Ceil),
+	"round": {minArgs: 1, maxArgs: 2, fn: builtin_round},
+	"min":   {minArgs: 1, maxArgs: variadic, fn: builtin_min},
+	"max":   {minArgs: 1, maxArgs: variadic, fn: builtin_max},
+	// Complex number parts, trivial for real numbers.
+	"re":   unary_builtin(func(x float64) float64 { return x }),
+	"im":   unary_builtin(func(x float64) float64 { return 0 }),
+	"conj": unary_builtin(func(x float64) float64 { return x }),
+	"arg":  {minArgs: 1, maxArgs: 1, fn: builtin_arg},
+	// Calendar functions. Dates are seconds since 1970-01-01 in float64
+	// arithmetic; evaluators with dates give them typed results.
+	"weekday":  {minArgs: 1, maxArgs: 1, fn: builtin_weekday},
+	"workday":  {minArgs: 2, maxArgs: 2, fn: builtin_workday},
+	"workdays": {minArgs: 2, maxArgs: 2, fn: builtin_workdays},
+}
+
+// IsFunction reports whether name is a built-in function.
+func IsFunction(name string) bool {
+	_, exists := builtins[name]
+	return exists
+}
+
+// CallFunction calls the built-in function name with args in float64 arithmetic.
+func CallFunction(env *Env, name string, args []float64) (float64, error) {
+	fn, exists := builtins[name]
+	if !exists {
+		return 0, &UnknownFunctionError{Name: name}
+	}
+	if !fn.accepts(len(args)) {
+		return 0, &ArityError{Name: name, Got: len(args), Min: fn.minArgs, Max: fn.maxArgs}
+	}
+	return fn.fn(env, args), nil
+}
+
+// Functions returns the names of the built-in functions in alphabetical order.
+func Functions() []string {
+	return slices.Sorted(maps.Keys(builtins))
+}
+
+func unary_builtin(fn func(float64) float64) builtin {
+	return builtin{
+		minArgs: 1,
+		maxArgs: 1,
+		fn:      func(_ *Env, args []float64) float64 { return fn(args[0]) },
+	}
+}
+
+// angle_builtin wraps a trigonometric function so its argument is read in the
+// environment's angle mode. exact holds the results for 0, 1, 2 and 3 quarter turns.
+func angle_builtin(fn func(float64) float64, exact [4]float64) builtin {
+	return builtin{
+		minArgs: 1,
+		maxArgs: 1,
+		fn: func(env *Env, args []float64) float64 {
+			if turns, ok := env.Angle.quarterTurns(args[0]); ok {
+				return exact[turns]
+			}
+			return fn(env.Angle.ToRadians(args[0]))
+		},
+	}
+}
+
+// inverse_angle_builtin wraps an inverse trigonometric function so its result
+// is given in the environment's angle mode.
+func inverse_angle_builtin(fn func(float64) float64) builtin {
+	return builtin{
+		minArgs: 1,
+		maxArgs: 1,
+		fn:      func(env *Env, args []float64) float64 { return env.Angle.FromRadians(fn(args[0])) },
+	}
+}
+
+// builtin_round rounds half away from zero, optionally to a number of decimal places.
+func builtin_round(_ *Env, args []float64) float64 {
+	if len(args) == 1 {
+		return math.Round(args[0])
+	}
+	factor := math.Pow(10, math.Trunc(args[1]))
+	return math.Round(args[0]*factor) / factor
+}
+
+func builtin_min(_ *Env, args []float64) float64 {
+	return slices.Min(args)
+}
+
+func builtin_max(_ *Env, args []float64) float64 {
+	return slices.Max(args)
+}
+
+// builtin_arg returns the argument (phase angle) of a real number: 0 for
+// positive numbers and half a turn for negative ones.
+func builtin_arg(env *Env, args []float64) float64 {
+	if args[0] < 0 {
+		return env.Angle.FromRadians(math.Pi)
+	}
+	return 0
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package parser
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layouts of the date literals, with and without a time of day.
+const (
+	DateLayout      = time.DateOnly
+	DateClockLayout = "2006-01-02 15:04"
+	DateTimeLayout  = time.DateTime
+)
+
+// ParseDate converts the text of a DATE token to the date it names, in UTC so
+// that every day has 24 hours. clock reports whether a time of day was given.
+// The lexer only checks the shape of the literal, so "2026-13-01" and
+// "2026-02-30" fail here.
+func ParseDate(literal string) (date time.Time, clock bool, err error) {
+	text := strings.Replace(literal, "T", " ", 1)
+	switch len(text) {
+	case len(DateLayout):
+		date, err = time.Parse(DateLayout, text)
+	case len(DateClockLayout):
+		date, err = time.Parse(DateClockLayout, text)
+		clock = true
+	default:
+		date, err = time.Parse(DateTimeLayout, text)
+		clock = true
+	}
+	return date, clock, err
+}
+
+// FormatDate writes date the way it is typed, with the time of day when clock
+// is set, and its seconds only when there are some.
+func FormatDate(date time.Time, clock bool) string {
+	switch {
+	case !clock:
+		return date.Format(DateLayout)
+	case date.Second() == 0:
+		return date.Format(DateClockLayout)
+	}
+	return date.Format(DateTimeLayout)
+}
+
+// durationParts splits a DURATION token into its numbers and their units.
+var durationParts = regexp.MustCompile(`([0-9]+(?:\.[0-9]+)?)(w|d|h|min|m|s)`)
+
+// durationSeconds is the length of each unit of a duration literal in seconds.
+var durationSeconds = map[string]float64{
+	"w": 7 * 86400, "d": 86400, "h": 3600, "min": 60, "m": 60, "s": 1,
+}
+
+// ParseDuration converts the text of a DURATION token, such as "3h 45m", to
+// seconds.
+func ParseDuration(literal string) (float64, error) {
+	seconds := 0.0
+	for _, part := range durationParts.FindAllStringSubmatch(literal, -1) {
+		amount, err := strconv.ParseFloat(part[1], 64)
+		if err != nil {
+			return 0, err
+		}
+		seconds += amount * durationSeconds[part[2]]
+	}
+	return seconds, nil
+}
+
+// IsoWeekday returns the ISO 8601 number of the day of the week of date, from
+// 1 for Monday to 7 for Sunday.
+func IsoWeekday(date time.Time) int {
+	if date.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(date.Weekday())
+}
+
+// isWorkday reports whether date falls from Monday to Friday.
+func isWorkday(date time.Time) bool {
+	return IsoWeekday(date) <= 5
+}
+
+// AddWorkdays returns the date n business days after date, or before it when n
+// is negative. Business days are Monday to Friday; holidays are not known.
+// The time of day is kept.
+func AddWorkdays(date time.Time, n int) time.Time {
+	if n == 0 {
+		return date
+	}
+	step := 1
+	if n < 0 {
+		step = -1
+	}
+	// From a weekend, count from the Friday before or the Monday after it,
+	// so that whole weeks can be skipped at once.
+	for !isWorkday(date) {
+		date = date.AddDate(0, 0, -step)
+	}
+	date = date.AddDate(0, 0, 7*(n/5))
+	for rest := n % 5; rest != 0; rest -= step {
+		date = date.AddDate(0, 0, step)
+		for !isWorkday(date) {
+			date = date.AddDate(0, 0, step)
+		}
+	}
+	return date
+}
+
+// CountWorkdays returns the number of business days after from up to and
+// including to, negative when to is before from. AddWorkdays(from, n) is to
+// for that n when to is a business day.
+func CountWorkdays(from, to time.Time) int {
+	return workdaysSinceEpoch(to) - workdaysSinceEpoch(from)
+}
+
+// workdaysSinceEpoch counts the business days from Monday 1970-01-05 up to
+// and including date, negative for earlier dates.
+func workdaysSinceEpoch(date time.Time) int {
+	monday := time.Date(1970, time.January, 5, 0, 0, 0, 0, time.UTC)
+	year, month, day := date.Date()
+	days := int((time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() - monday.Unix()) / 86400)
+	weeks, weekday := floorDiv(days, 7)
+	return 5*weeks + min(weekday+1, 5)
+}
+
+// floorDiv divides a by b rounding down, returning the quotient and the
+// remainder, which has the sign of b.
+func floorDiv(a, b int) (int, int) {
+	q, r := a/b, a%b
+	if r != 0 && (r < 0) != (b < 0) {
+		q--
+		r += b
+	}
+	return q, r
+}
+
+// UnixDate returns the date seconds after 1970-01-01 in UTC, which is how
+// dates are represented in float64 arithmetic.
+func UnixDate(seconds float64) time.Time {
+	whole, fraction := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(fraction*1e9)).UTC()
+}
+
+// UnixSeconds returns date as seconds after 1970-01-01.
+func UnixSeconds(date time.Time) float64 {
+	return float64(date.Unix()) + float64(date.Nanosecond())/1e9
+}
+
+// builtin_weekday returns the ISO number of the day of the week of a date.
+func builtin_weekday(_ *Env, args []float64) float64 {
+	return float64(IsoWeekday(UnixDate(args[0])))
+}
+
+// builtin_workday returns the date a number of business days after a date.
+func builtin_workday(_ *Env, args []float64) float64 {
+	return UnixSeconds(AddWorkdays(UnixDate(args[0]), int(args[1])))
+}
+
+// builtin_workdays counts the business days from a date to another.
+func builtin_workdays(_ *Env, args []float64) float64 {
+	return float64(CountWorkdays(UnixDate(args[0]), UnixDate(args[1])))
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package parser
+
+import (
+	"maps"
+	"math"
+	"slices"
+)
+
+// Constant is a named value that can be used in expressions without being assigned.
+type Constant struct {
+	// Name is the identifier the constant is referred to by.
+	Name string
+	// Value is the value of the constant in SI units.
+	Value float64
+	// Unit is the SI unit of Value written as an expression, such as "m/s".
+	// It is empty for mathematical constants.
+	Unit string
+	// Description is a human readable name for the constant.
+	Description string
+}
+
+// constants is the table of predefined constants, keyed by name.
+// Physical values are the CODATA 2018 recommended values; those fixed by
+// the 2019 SI redefinition are exact.
+var constants = map[string]Constant{
+	"pi":    {"pi", math.Pi, "", "Ratio of a circle's circumference to its diameter"},
+	"tau":   {"tau", 2 * math.Pi, "", "Ratio of a circle's circumference to its radius"},
+	"e":     {"e", math.E, "", "Euler's number, base of the natural logarithm"},
+	"phi":   {"phi", math.Phi, "", "Golden ratio"},
+	"c":     {"c", 299792458, "m/s", "Speed of light in vacuum"},
+	"G":     {"G", 6.67430e-11, "m^3/(kg*s^2)", "Newtonian constant of gravitation"},
+	"g0":    {"g0", 9.80665, "m/s^2", "Standard acceleration of gravity"},
+	"h":     {"h", 6.62607015e-34, "J*s", "Planck constant"},
+	"hbar":  {"hbar", 1.054571817e-34, "J*s", "Reduced Planck constant"},
+	"NA":    {"NA", 6.02214076e23, "1/mol", "Avogadro constant"},
+	"kB":    {"kB", 1.380649e-23, "J/K", "Boltzmann constant"},
+	"R":     {"R", 8.314462618, "J/(mol*K)", "Molar gas constant"},
+	"qe":    {"qe", 1.602176634e-19, "C", "Elementary charge"},
+	"me":    {"me", 9.1093837015e-31, "kg", "Electron mass"},
+	"mp":    {"mp", 1.67262192369e-27, "kg", "Proton mass"},
+	"eps0":  {"eps0", 8.8541878128e-12, "F/m", "Vacuum electric permittivity"},
+	"mu0":   {"mu0", 1.25663706212e-6, "N/A^2", "Vacuum magnetic permeability"},
+	"sigma": {"sigma", 5.670374419e-8, "W/(m^2*K^4)", "Stefan-Boltzmann constant"},
+}
+
+// LookupConstant returns the constant called name and whether it exists.
+func LookupConstant(name string) (Constant, bool) {
+	constant, exists := constants[name]
+	return constant, exists
+}
+
+// Constants returns every predefined constant sorted by name.
+func Constants() []Constant {
+	list := make([]Constant, 0, len(constants))
+	for _, name := range slices.Sorted(maps.Keys(constants)) {
+		list = append(list, constants[name])
+	}
+	return list
+}
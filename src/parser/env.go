@@ -0,0 +1,63 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package parser
+
+import (
+	"maps"
+	"slices"
+)
+
+// Env holds the state an expression is evaluated against.
+// It is meant to outlive a single evaluation so that values assigned
+// in one equation can be reused by the next ones.
+type Env struct {
+	// Vars maps variable names to their current values.
+	Vars map[string]float64
+	// Angle is the unit trigonometric functions read and return angles in.
+	Angle AngleMode
+	// exact holds the value of variables assigned by evaluators working with
+	// other number types than float64, in that evaluator's own type.
+	// Vars always holds a float64 approximation of them as well.
+	exact map[string]any
+}
+
+// NewEnv creates an empty evaluation environment working in radians.
+func NewEnv() *Env {
+	return &Env{
+		Vars: make(map[string]float64),
+	}
+}
+
+// Get returns the value of the variable name and whether it is defined.
+func (e *Env) Get(name string) (float64, bool) {
+	value, exists := e.Vars[name]
+	return value, exists
+}
+
+// Set defines or overwrites the variable name.
+func (e *Env) Set(name string, value float64) {
+	e.Vars[name] = value
+	delete(e.exact, name)
+}
+
+// SetExact defines or overwrites the variable name with a value of another
+// number type than float64, along with its float64 approximation.
+func (e *Env) SetExact(name string, approx float64, exact any) {
+	if e.exact == nil {
+		e.exact = make(map[string]any)
+	}
+	e.Vars[name] = approx
+	e.exact[name] = exact
+}
+
+// GetExact returns the value the variable name was given by SetExact, if it
+// has not been overwritten by Set since.
+func (e *Env) GetExact(name string) (any, bool) {
+	value, exists := e.exact[name]
+	return value, exists
+}
+
+// Names returns the defined variable names in alphabetical order.
+func (e *Env) Names() []string {
+	return slices.Sorted(maps.Keys(e.Vars))
+}
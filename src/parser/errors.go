@@ -0,0 +1,247 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package parser
+
+import (
+	"calculator/src/lexer"
+	"fmt"
+	"strings"
+)
+
+// UnexpectedTokenError reports a token that cannot appear where it was found.
+type UnexpectedTokenError struct {
+	// Token is the offending token.
+	Token lexer.Token
+	// Offset is the byte offset of Token in the source.
+	Offset int
+	// Expected lists the token kinds that would have been accepted, if known.
+	Expected []lexer.TokenKind
+}
+
+func (e *UnexpectedTokenError) Error() string {
+	if len(e.Expected) == 0 {
+		return fmt.Sprintf("unexpected %s at offset %d", e.Token.ToString(), e.Offset)
+	}
+
+	expected := make([]string, len(e.Expected))
+	for i, kind := range e.Expected {
+		expected[i] = lexer.TokenKindString(kind)
+	}
+	return fmt.Sprintf("expected %s but found %s at offset %d", strings.Join(expected, " or "), e.Token.ToString(), e.Offset)
+}
+
+// UnbalancedParenError reports a parenthesis or bracket without a matching partner.
+// Token is either an OPEN_PAREN or OPEN_BRACKET that is never closed or a
+// stray CLOSE_PAREN.
+type UnbalancedParenError struct {
+	// Token is the unmatched parenthesis.
+	Token lexer.Token
+	// Offset is the byte offset of Token in the source.
+	Offset int
+}
+
+func (e *UnbalancedParenError) Error() string {
+	if e.Token.Kind == lexer.OPEN_PAREN || e.Token.Kind == lexer.OPEN_BRACKET {
+		return fmt.Sprintf("unclosed '%s' at offset %d", e.Token.Value, e.Offset)
+	}
+	return fmt.Sprintf("unmatched '%s' at offset %d", e.Token.Value, e.Offset)
+}
+
+// MissingOperandError reports a place where an operand was expected but an
+// operator or the end of the input was found instead.
+type MissingOperandError struct {
+	// Token is the token found where the operand should be.
+	Token lexer.Token
+	// Offset is the byte offset of Token in the source.
+	Offset int
+}
+
+func (e *MissingOperandError) Error() string {
+	if e.Token.Kind == lexer.END {
+		return fmt.Sprintf("missing operand at end of input (offset %d)", e.Offset)
+	}
+	return fmt.Sprintf("missing operand before %s at offset %d", e.Token.ToString(), e.Offset)
+}
+
+// InvalidAssignmentError reports an assignment whose left-hand side is not a
+// variable name, such as "2 = 3".
+type InvalidAssignmentError struct {
+	// Token is the assignment operator.
+	Token lexer.Token
+	// Offset is the byte offset of Token in the source.
+	Offset int
+}
+
+func (e *InvalidAssignmentError) Error() string {
+	return fmt.Sprintf("only a variable name can be assigned to (offset %d)", e.Offset)
+}
+
+// UndefinedVariableError is returned by Eval when an expression refers to a
+// name that is not defined in the environment.
+type UndefinedVariableError struct {
+	// Name is the undefined variable.
+	Name string
+	// Span is the range of the source where Name is used.
+	Span lexer.Span
+}
+
+func (e *UndefinedVariableError) Error() string {
+	return fmt.Sprintf("undefined variable '%s' at offset %d", e.Name, e.Span.Start)
+}
+
+// ComplexNumberError is returned by Eval for the imaginary unit, which has no
+// real value.
+type ComplexNumberError struct {
+	// Span is the range of the source holding the imaginary unit.
+	Span lexer.Span
+}
+
+func (e *ComplexNumberError) Error() string {
+	return fmt.Sprintf("complex number at offset %d needs the Complex backend", e.Span.Start)
+}
+
+// IntervalError is returned by Eval for an interval such as "[9.8, 10.2]" or
+// a measurement such as "10 ± 0.2", which have no single real value.
+type IntervalError struct {
+	// Span is the range of the source holding the interval.
+	Span lexer.Span
+}
+
+func (e *IntervalError) Error() string {
+	return fmt.Sprintf("interval at offset %d needs the Interval or Uncertain backend", e.Span.Start)
+}
+
+// UnitError is returned by Eval for a quantity such as "5 km" or a unit
+// conversion, which need an evaluator that works with units.
+type UnitError struct {
+	// Span is the range of the source holding the quantity or conversion.
+	Span lexer.Span
+}
+
+func (e *UnitError) Error() string {
+	return fmt.Sprintf("units at offset %d need the Float backend", e.Span.Start)
+}
+
+// CalendarError is returned by Eval for a date such as "2026-03-01" or a
+// duration such as "3h 45m", which need an evaluator that works with dates.
+// The evaluator returns it with Err set for a date it cannot compute.
+type CalendarError struct {
+	// Span is the range of the source holding the date or duration.
+	Span lexer.Span
+	// Err is why the date could not be computed, as for a date moved beyond
+	// the years a date can be written with. The evaluator reports its offset.
+	Err error
+}
+
+func (e *CalendarError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("dates and durations at offset %d need the Float backend", e.Span.Start)
+}
+
+func (e *CalendarError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownFunctionError reports a call to a name that is not a built-in function.
+type UnknownFunctionError struct {
+	// Name is the called name.
+	Name string
+	// Span is the range of the source holding the name.
+	Span lexer.Span
+}
+
+func (e *UnknownFunctionError) Error() string {
+	return fmt.Sprintf("unknown function '%s' at offset %d", e.Name, e.Span.Start)
+}
+
+// ArityError reports a call with a number of arguments the function does not accept.
+type ArityError struct {
+	// Name is the called function.
+	Name string
+	// Got is the number of arguments in the call.
+	Got int
+	// Min and Max bound the accepted number of arguments. Max is -1 when unbounded.
+	Min int
+	Max int
+	// Span is the range of the source holding the whole call.
+	Span lexer.Span
+}
+
+func (e *ArityError) Error() string {
+	var accepted string
+	switch {
+	case e.Max == -1:
+		accepted = fmt.Sprintf("at least %d", e.Min)
+	case e.Min == e.Max:
+		accepted = fmt.Sprintf("%d", e.Min)
+	default:
+		accepted = fmt.Sprintf("%d to %d", e.Min, e.Max)
+	}
+	return fmt.Sprintf("%s takes %s argument(s) but got %d at offset %d", e.Name, accepted, e.Got, e.Span.Start)
+}
+
+// InvalidNumberError reports a numeric literal that cannot be represented,
+// such as one that overflows.
+type InvalidNumberError struct {
+	// Literal is the text of the literal.
+	Literal string
+	// Span is the range of the source holding the literal.
+	Span lexer.Span
+	// Err is the underlying conversion error.
+	Err error
+}
+
+func (e *InvalidNumberError) Error() string {
+	return fmt.Sprintf("invalid number '%s' at offset %d", e.Literal, e.Span.Start)
+}
+
+func (e *InvalidNumberError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidDateError reports a date literal naming a day that does not exist,
+// such as "2026-02-30".
+type InvalidDateError struct {
+	// Literal is the text of the literal.
+	Literal string
+	// Span is the range of the source holding the literal.
+	Span lexer.Span
+	// Err is the underlying conversion error.
+	Err error
+}
+
+func (e *InvalidDateError) Error() string {
+	return fmt.Sprintf("invalid date '%s' at offset %d", e.Literal, e.Span.Start)
+}
+
+func (e *InvalidDateError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidBaseError reports a base conversion to a base that is not an
+// integer from 2 to 36, as in "255 to base 40".
+type InvalidBaseError struct {
+	// Literal is the text of the base.
+	Literal string
+	// Span is the range of the source holding the base.
+	Span lexer.Span
+}
+
+func (e *InvalidBaseError) Error() string {
+	return fmt.Sprintf("base '%s' at offset %d must be an integer from %d to %d", e.Literal, e.Span.Start, MinBase, MaxBase)
+}
+
+// TrailingTokenError reports input left over after a complete expression,
+// as in "2 3" or "1+2)4" with the extra ")" reported as unbalanced instead.
+type TrailingTokenError struct {
+	// Token is the first token that was not consumed.
+	Token lexer.Token
+	// Offset is the byte offset of Token in the source.
+	Offset int
+}
+
+func (e *TrailingTokenError) Error() string {
+	return fmt.Sprintf("unexpected %s after the end of the expression at offset %d", e.Token.ToString(), e.Offset)
+}
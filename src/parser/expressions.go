@@ -6,27 +6,209 @@ import (
 	"calculator/src/lexer"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Expr represents an equation expression that can be converted to a string and evaluated.
 type Expr interface {
-	// ToString returns a string representation of the expression.
+	// ToString returns a string representation of the expression, written
+	// with ASCII symbols.
 	ToString() string
+	// Render returns a string representation of the expression written with
+	// the symbols of style.
+	Render(style Style) string
 	// Eval computes and returns the value of the expression.
-	Eval() float64
+	// Variables are resolved and assigned through env.
+	Eval(env *Env) (float64, error)
+	// Position returns the range of the source the expression was parsed from.
+	// Parentheses wrapping the whole expression are not included.
+	Position() lexer.Span
 }
 
 // NumberExpr represents a numeric expression.
 type NumberExpr struct {
 	// Value holds the numeric value of the expression.
 	Value float64
+	// Literal is the number as written in the source, for evaluators that
+	// need more precision than Value holds.
+	Literal string
+	// Err is set when Literal is beyond the range of float64, as "1e400".
+	// Value is then only the closest float64, and the number can only be
+	// used by evaluators that read Literal themselves.
+	Err error
+	// Span is the range of the source holding the literal.
+	Span lexer.Span
 }
 
 func (n NumberExpr) ToString() string {
+	return n.Render(ASCII)
+}
+func (n NumberExpr) Render(style Style) string {
+	if n.Err != nil {
+		return n.Literal
+	}
 	return fmt.Sprintf("%g", n.Value)
 }
-func (n NumberExpr) Eval() float64 {
-	return n.Value
+func (n NumberExpr) Eval(env *Env) (float64, error) {
+	return n.Float64()
+}
+
+// Float64 returns Value, or an *InvalidNumberError when Literal is beyond
+// the range of float64.
+func (n NumberExpr) Float64() (float64, error) {
+	if n.Err != nil {
+		return 0, &InvalidNumberError{Literal: n.Literal, Span: n.Span, Err: n.Err}
+	}
+	return n.Value, nil
+}
+func (n NumberExpr) Position() lexer.Span {
+	return n.Span
+}
+
+// ImaginaryUnit is the name of the imaginary unit, the square root of -1.
+// Numbers such as "4i" are written as a multiple of it.
+const ImaginaryUnit = "i"
+
+// ImaginaryExpr represents the imaginary unit "i".
+// Only an evaluator working with complex numbers can compute it; Eval fails.
+type ImaginaryExpr struct {
+	// Span is the range of the source holding the unit.
+	Span lexer.Span
+}
+
+func (n ImaginaryExpr) ToString() string {
+	return n.Render(ASCII)
+}
+func (n ImaginaryExpr) Render(style Style) string {
+	return ImaginaryUnit
+}
+func (n ImaginaryExpr) Eval(env *Env) (float64, error) {
+	return 0, &ComplexNumberError{Span: n.Span}
+}
+func (n ImaginaryExpr) Position() lexer.Span {
+	return n.Span
+}
+
+// IntervalExpr represents an interval given by its bounds, as in "[9.8, 10.2]".
+// Only an evaluator working with intervals can compute it; Eval fails.
+type IntervalExpr struct {
+	// Lo and Hi are the expressions of the lower and upper bound.
+	Lo Expr
+	Hi Expr
+	// Span is the range of the source from the opening to the closing bracket.
+	Span lexer.Span
+}
+
+func (n IntervalExpr) ToString() string {
+	return n.Render(ASCII)
+}
+func (n IntervalExpr) Render(style Style) string {
+	return fmt.Sprintf("[%s, %s]", n.Lo.Render(style), n.Hi.Render(style))
+}
+func (n IntervalExpr) Eval(env *Env) (float64, error) {
+	return 0, &IntervalError{Span: n.Span}
+}
+func (n IntervalExpr) Position() lexer.Span {
+	return n.Span
+}
+
+// QuantityExpr represents a value measured in a unit, as in "5 km/h".
+// Only an evaluator working with units can compute it; Eval fails.
+type QuantityExpr struct {
+	// Value is the expression of the amount.
+	Value Expr
+	// Unit is the unit as written, such as "km/h" or "m/s^2". Units written
+	// next to each other, as in "N m", are joined with "*".
+	Unit string
+	// Span is the range of the source from the start of Value to the end of Unit.
+	Span lexer.Span
+}
+
+func (n QuantityExpr) ToString() string {
+	return n.Render(ASCII)
+}
+func (n QuantityExpr) Render(style Style) string {
+	return fmt.Sprintf("(%s %s)", n.Value.Render(style), n.Unit)
+}
+func (n QuantityExpr) Eval(env *Env) (float64, error) {
+	return 0, &UnitError{Span: n.Span}
+}
+func (n QuantityExpr) Position() lexer.Span {
+	return n.Span
+}
+
+// DateExpr represents a date literal, as in "2026-03-01" or "2026-03-01T09:30".
+// Only an evaluator working with dates can compute it; Eval fails.
+type DateExpr struct {
+	// Value is the date, in UTC.
+	Value time.Time
+	// Clock is set when the literal gives a time of day.
+	Clock bool
+	// Span is the range of the source holding the literal.
+	Span lexer.Span
+}
+
+func (n DateExpr) ToString() string {
+	return n.Render(ASCII)
+}
+func (n DateExpr) Render(style Style) string {
+	return FormatDate(n.Value, n.Clock)
+}
+func (n DateExpr) Eval(env *Env) (float64, error) {
+	return 0, &CalendarError{Span: n.Span}
+}
+func (n DateExpr) Position() lexer.Span {
+	return n.Span
+}
+
+// DurationExpr represents a duration literal, as in "3h 45m" or "2w".
+// Only an evaluator working with dates can compute it; Eval fails.
+type DurationExpr struct {
+	// Seconds is the length of the duration.
+	Seconds float64
+	// Literal is the duration as written in the source.
+	Literal string
+	// Span is the range of the source holding the literal.
+	Span lexer.Span
+}
+
+func (n DurationExpr) ToString() string {
+	return n.Render(ASCII)
+}
+func (n DurationExpr) Render(style Style) string {
+	return n.Literal
+}
+func (n DurationExpr) Eval(env *Env) (float64, error) {
+	return 0, &CalendarError{Span: n.Span}
+}
+func (n DurationExpr) Position() lexer.Span {
+	return n.Span
+}
+
+// ConversionExpr represents the conversion of a value to another unit, as in
+// "5 km/h to mph". Only an evaluator working with units can compute it; Eval fails.
+type ConversionExpr struct {
+	// Value is the expression being converted.
+	Value Expr
+	// Unit is the target unit as written after "to".
+	Unit string
+	// Span is the range of the source from the start of Value to the end of Unit.
+	Span lexer.Span
+}
+
+func (n ConversionExpr) ToString() string {
+	return n.Render(ASCII)
+}
+func (n ConversionExpr) Render(style Style) string {
+	return fmt.Sprintf("(%s to %s)", n.Value.Render(style), n.Unit)
+}
+func (n ConversionExpr) Eval(env *Env) (float64, error) {
+	return 0, &UnitError{Span: n.Span}
+}
+func (n ConversionExpr) Position() lexer.Span {
+	return n.Span
 }
 
 // BinaryExpr represents an expression with a binary operator.
@@ -38,39 +220,90 @@ type BinaryExpr struct {
 	Operator lexer.Token
 	// Right is the expression on the right side of the operator.
 	Right Expr
+	// Implicit is set when the multiplication was not written but inferred from
+	// two adjacent operands, as in "2(3+4)". Operator is then a zero-width "*".
+	Implicit bool
+	// Span is the range of the source from the start of Left to the end of Right.
+	// Parentheses around the operands are included.
+	Span lexer.Span
+}
+
+func (n BinaryExpr) Position() lexer.Span {
+	return n.Span
 }
 
 func (n BinaryExpr) ToString() string {
-	return fmt.Sprintf("(%s %s %s)", n.Left.ToString(), n.Operator.Value, n.Right.ToString())
+	return n.Render(ASCII)
 }
-func (n BinaryExpr) Eval() float64 {
-	a := n.Left.Eval()
-	b := n.Right.Eval()
+func (n BinaryExpr) Render(style Style) string {
+	if n.Operator.Kind == lexer.HAT {
+		return renderPower(style, n.Left, n.Operator, n.Right)
+	}
+	return fmt.Sprintf("(%s %s %s)", n.Left.Render(style), style.operator(n.Operator), n.Right.Render(style))
+}
+func (n BinaryExpr) Eval(env *Env) (float64, error) {
+	a, err := n.Left.Eval(env)
+	if err != nil {
+		return 0, err
+	}
+	b, err := n.Right.Eval(env)
+	if err != nil {
+		return 0, err
+	}
 
-	switch n.Operator.Kind {
+	if n.Operator.Kind == lexer.PLUS_MINUS {
+		return 0, &IntervalError{Span: n.Span}
+	}
+
+	// A percentage added to or subtracted from a value is relative to it,
+	// the way desk calculators work: "200 + 10%" is 220.
+	if IsPercent(n.Right) && (n.Operator.Kind == lexer.PLUS || n.Operator.Kind == lexer.DASH) {
+		b = a * b
+	}
+
+	return BinaryOp(n.Operator.Kind, a, b)
+}
+
+// BinaryOp applies the binary operator kind to a and b in float64 arithmetic.
+func BinaryOp(kind lexer.TokenKind, a, b float64) (float64, error) {
+	switch kind {
 	case lexer.PLUS:
-		return a + b
+		return a + b, nil
 	case lexer.DASH:
-		return a - b
+		return a - b, nil
 	case lexer.STAR:
-		return round(a*b, 10)
+		return round(a * b), nil
 	case lexer.SLASH:
-		return round(a/b, 10)
+		return round(a / b), nil
 	case lexer.PERCENT:
-		return math.Remainder(a, b)
+		return math.Remainder(a, b), nil
 	case lexer.ROOT:
-		return round(math.Pow(a, 1/b), 10)
+		return round(math.Pow(a, 1/b)), nil
 	case lexer.HAT:
-		return round(math.Pow(a, b), 10)
+		return round(math.Pow(a, b)), nil
 	case lexer.LOG:
-		return round(math.Log(a)/math.Log(b), 10)
+		return round(math.Log(a) / math.Log(b)), nil
+	case lexer.AMPERSAND, lexer.PIPE, lexer.XOR, lexer.SHIFT_LEFT, lexer.SHIFT_RIGHT:
+		return bitwiseOp(kind, a, b)
 	default:
-		panic(fmt.Sprintf("Operator %s not recognized", n.Operator.KindString()))
+		return 0, fmt.Errorf("operator %s not recognized", lexer.TokenKindString(kind))
 	}
 }
-func round(value float64, precision int) float64 {
-	factor := math.Pow(10, float64(precision))
-	return math.Round(value*factor) / factor
+
+// significantDigits is the number of significant digits round keeps, the
+// most a float64 always holds.
+const significantDigits = 15
+
+// round drops the digits of value beyond significantDigits, where the
+// binary approximations of decimal fractions leave noise, so that 1.1 * 3
+// is 3.3 rather than 3.3000000000000003. Small values keep their digits:
+// 6.674e-11 * 2 is 1.3348e-10.
+func round(value float64) float64 {
+	if value == math.Trunc(value) || math.IsInf(value, 0) {
+		return value
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(value, 'g', significantDigits, 64), 64)
+	return rounded
 }
 
 // UnaryExpr represents an expression with a unary operator.
@@ -80,16 +313,213 @@ type UnaryExpr struct {
 	Operator lexer.Token
 	// Member is the expression on which the operator is applied.
 	Member Expr
+	// Span is the range of the source from the operator to the end of Member.
+	// Parentheses around Member are included.
+	Span lexer.Span
+}
+
+func (n UnaryExpr) Position() lexer.Span {
+	return n.Span
 }
 
 func (n UnaryExpr) ToString() string {
-	return fmt.Sprintf("(%s%s)", n.Operator.Value, n.Member.ToString())
+	return n.Render(ASCII)
+}
+func (n UnaryExpr) Render(style Style) string {
+	return fmt.Sprintf("(%s%s)", style.operator(n.Operator), n.Member.Render(style))
+}
+func (n UnaryExpr) Eval(env *Env) (float64, error) {
+	member, err := n.Member.Eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	return UnaryOp(n.Operator.Kind, member)
 }
-func (n UnaryExpr) Eval() float64 {
-	switch n.Operator.Kind {
+
+// UnaryOp applies the prefix operator kind to a in float64 arithmetic.
+func UnaryOp(kind lexer.TokenKind, a float64) (float64, error) {
+	switch kind {
 	case lexer.DASH:
-		return -1 * n.Member.Eval()
+		return -1 * a, nil
+	case lexer.TILDE:
+		x, err := toInt64(a)
+		if err != nil {
+			return 0, err
+		}
+		return float64(^x), nil
+	default:
+		return 0, fmt.Errorf("operator %s not recognized", lexer.TokenKindString(kind))
+	}
+}
+
+// IdentifierExpr represents a reference to a variable or constant by name.
+// Variables defined in the environment shadow constants with the same name.
+type IdentifierExpr struct {
+	// Name is the variable name.
+	Name string
+	// Span is the range of the source holding the name.
+	Span lexer.Span
+}
+
+func (n IdentifierExpr) ToString() string {
+	return n.Render(ASCII)
+}
+func (n IdentifierExpr) Render(style Style) string {
+	if style == Unicode && n.Name == "pi" {
+		return "π"
+	}
+	return n.Name
+}
+func (n IdentifierExpr) Eval(env *Env) (float64, error) {
+	if value, exists := env.Get(n.Name); exists {
+		return value, nil
+	}
+	if constant, exists := LookupConstant(n.Name); exists {
+		return constant.Value, nil
+	}
+	return 0, &UndefinedVariableError{Name: n.Name, Span: n.Span}
+}
+func (n IdentifierExpr) Position() lexer.Span {
+	return n.Span
+}
+
+// AssignmentExpr represents the assignment of a value to a variable, as in "rate = 0.07".
+// Evaluating it stores the value in the environment and returns it.
+type AssignmentExpr struct {
+	// Target is the variable being assigned.
+	Target IdentifierExpr
+	// Value is the expression whose result is stored in Target.
+	Value Expr
+	// Span is the range of the source from Target to the end of Value.
+	Span lexer.Span
+}
+
+func (n AssignmentExpr) ToString() string {
+	return n.Render(ASCII)
+}
+func (n AssignmentExpr) Render(style Style) string {
+	return fmt.Sprintf("(%s = %s)", n.Target.Render(style), n.Value.Render(style))
+}
+func (n AssignmentExpr) Eval(env *Env) (float64, error) {
+	value, err := n.Value.Eval(env)
+	if err != nil {
+		return 0, err
+	}
+	env.Set(n.Target.Name, value)
+	return value, nil
+}
+func (n AssignmentExpr) Position() lexer.Span {
+	return n.Span
+}
+
+// CallExpr represents a call to a built-in function, as in "max(a, 2)".
+type CallExpr struct {
+	// Name is the called function.
+	Name string
+	// Args are the argument expressions in call order.
+	Args []Expr
+	// Span is the range of the source from Name to the closing parenthesis.
+	Span lexer.Span
+}
+
+func (n CallExpr) ToString() string {
+	return n.Render(ASCII)
+}
+func (n CallExpr) Render(style Style) string {
+	// Operands other than numbers, names and calls are written in
+	// parentheses, so the radical sign covers all of the operand.
+	if style == Unicode && n.Name == "sqrt" && len(n.Args) == 1 {
+		return "√" + n.Args[0].Render(style)
+	}
+	args := make([]string, len(n.Args))
+	for i, arg := range n.Args {
+		args[i] = arg.Render(style)
+	}
+	return fmt.Sprintf("%s(%s)", n.Name, strings.Join(args, ", "))
+}
+func (n CallExpr) Eval(env *Env) (float64, error) {
+	if !IsFunction(n.Name) {
+		return 0, &UnknownFunctionError{Name: n.Name, Span: n.Span}
+	}
+
+	args := make([]float64, len(n.Args))
+	for i, arg := range n.Args {
+		value, err := arg.Eval(env)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = value
+	}
+
+	return CallFunction(env, n.Name, args)
+}
+func (n CallExpr) Position() lexer.Span {
+	return n.Span
+}
+
+// PostfixExpr represents an operator written after its operand, such as
+// "5!" (factorial), "10%" (percent) or "90°" (degrees).
+type PostfixExpr struct {
+	// Operator is the token representing the postfix operator.
+	Operator lexer.Token
+	// Member is the expression on which the operator is applied.
+	Member Expr
+	// Span is the range of the source from the start of Member to the operator.
+	Span lexer.Span
+}
+
+func (n PostfixExpr) ToString() string {
+	return n.Render(ASCII)
+}
+func (n PostfixExpr) Render(style Style) string {
+	return fmt.Sprintf("(%s%s)", n.Member.Render(style), n.Operator.Value)
+}
+func (n PostfixExpr) Eval(env *Env) (float64, error) {
+	member, err := n.Member.Eval(env)
+	if err != nil {
+		return 0, err
+	}
+
+	return PostfixOp(env, n.Operator.Kind, member)
+}
+
+// PostfixOp applies the postfix operator kind to a in float64 arithmetic.
+// Angles marked with "°" are converted to the angle mode of env.
+func PostfixOp(env *Env, kind lexer.TokenKind, a float64) (float64, error) {
+	switch kind {
+	case lexer.BANG:
+		return Factorial(a), nil
+	case lexer.PERCENT:
+		return a / 100, nil
+	case lexer.DEGREE:
+		return env.Angle.FromRadians(Degrees.ToRadians(a)), nil
 	default:
-		panic(fmt.Sprintf("Operator %s not recognized", n.Operator.KindString()))
+		return 0, fmt.Errorf("operator %s not recognized", lexer.TokenKindString(kind))
+	}
+}
+func (n PostfixExpr) Position() lexer.Span {
+	return n.Span
+}
+
+// IsPercent reports whether expr is a postfix percentage such as "10%".
+func IsPercent(expr Expr) bool {
+	postfix, isPostfix := expr.(PostfixExpr)
+	return isPostfix && postfix.Operator.Kind == lexer.PERCENT
+}
+
+// Factorial returns n! for non-negative integers and extends it to other
+// values through the gamma function, n! = Γ(n+1). It is NaN for negative integers.
+func Factorial(n float64) float64 {
+	if n == math.Trunc(n) {
+		if n < 0 {
+			return math.NaN()
+		}
+		result := 1.0
+		for i := 2.0; i <= n && !math.IsInf(result, 1); i++ {
+			result *= i
+		}
+		return result
 	}
+	return math.Gamma(n + 1)
 }
@@ -4,7 +4,10 @@ package parser
 
 import (
 	"calculator/src/lexer"
+	"calculator/src/units"
+	"errors"
 	"strconv"
+	"strings"
 )
 
 // binding_power defines the precedence level for operators during parsing.
@@ -12,11 +15,37 @@ type binding_power int
 
 const (
 	default_bp binding_power = iota
+	assignment
+	// conversion binds "to" looser than any arithmetic, so that
+	// "1 km + 500 m to mi" converts the sum.
+	conversion
 	primary
+	// The bitwise operators bind looser than arithmetic, in the order of C,
+	// so that "1 << 2 + 1" is 1 << 3 and "a & b | c" is (a & b) | c.
+	bitwise_or
+	bitwise_xor
+	bitwise_and
+	shift
 	additive
 	multiplicative
-	exponential
+	// tolerance binds a "±" tighter than the arithmetic around it, so that
+	// "2 * 10 ± 0.2" is 2 * (10 ± 0.2).
+	tolerance
+	// unary sits below exponential so that a prefix minus takes the power
+	// with it: "-2^2" is -(2^2) while "-2*3" is (-2)*3.
 	unary
+	exponential
+	postfix_bp
+)
+
+// associativity decides how a chain of operators with the same binding power groups.
+type associativity int
+
+const (
+	// left_assoc groups from the left: "8 / 4 / 2" is (8 / 4) / 2.
+	left_assoc associativity = iota
+	// right_assoc groups from the right: "2 ^ 3 ^ 2" is 2 ^ (3 ^ 2).
+	right_assoc
 )
 
 // nud_lookup maps a lexer.TokenKind to its corresponding null denotation handler.
@@ -27,30 +56,58 @@ type nud_lookup map[lexer.TokenKind]nud_handler
 // A left denotation handler parses expressions that have a left-hand side.
 type led_lookup map[lexer.TokenKind]led_handler
 
+// postfix_lookup maps a lexer.TokenKind to its corresponding postfix handler.
+// A postfix handler applies an operator written after its operand, with no right-hand side.
+type postfix_lookup map[lexer.TokenKind]postfix_handler
+
 // bp_lookup maps a lexer.TokenKind to its binding power.
 // This helps determine operator precedence during parsing.
 type bp_lookup map[lexer.TokenKind]binding_power
 
+// assoc_lookup maps a lexer.TokenKind used as an infix operator to its associativity.
+type assoc_lookup map[lexer.TokenKind]associativity
+
 // bp_lu is the lookup table for binding powers.
 var bp_lu = bp_lookup{}
 
+// assoc_lu is the lookup table for operator associativity.
+var assoc_lu = assoc_lookup{}
+
 // nud_lu is the lookup table for null denotation (nud) handlers.
 var nud_lu = nud_lookup{}
 
 // led_lu is the lookup table for left denotation (led) handlers.
 var led_lu = led_lookup{}
 
+// postfix_lu is the lookup table for postfix handlers, such as the one for "!".
+var postfix_lu = postfix_lookup{}
+
+// postfix_bp_lu is the lookup table for the binding powers of postfix operators.
+// It is separate from bp_lu because a token such as "%" can also be an infix operator.
+var postfix_bp_lu = bp_lookup{}
+
+// operand_starts lists the token kinds that begin an operand. One of them right
+// after a complete operand is an implicit multiplication, and a token that is
+// both a postfix and an infix operator is only infix when one of them follows it.
+var operand_starts = []lexer.TokenKind{lexer.NUMBER, lexer.IDENTIFIER, lexer.OPEN_PAREN, lexer.OPEN_BRACKET, lexer.SQUARE_ROOT}
+
 // nud_handler defines a function type for parsing expressions without a left-hand side.
-// It takes a pointer to a parser and returns an expression.
-type nud_handler func(p *parser) Expr
+// It takes a pointer to a parser and returns an expression or a syntax error.
+type nud_handler func(p *parser) (Expr, error)
 
 // led_handler defines a function type for parsing expressions with a left-hand side.
 // It takes a pointer to a parser, the left-hand expression, and the current binding power,
-// and returns a new expression.
-type led_handler func(p *parser, left Expr, bp binding_power) Expr
+// and returns a new expression or a syntax error.
+type led_handler func(p *parser, left Expr, bp binding_power) (Expr, error)
 
-func led(kind lexer.TokenKind, bp binding_power, led_fn led_handler) {
+// postfix_handler defines a function type for parsing postfix operators.
+// It takes a pointer to a parser positioned on the operator and the operand
+// before it, and returns a new expression or a syntax error.
+type postfix_handler func(p *parser, left Expr) (Expr, error)
+
+func led(kind lexer.TokenKind, bp binding_power, assoc associativity, led_fn led_handler) {
 	bp_lu[kind] = bp
+	assoc_lu[kind] = assoc
 	led_lu[kind] = led_fn
 }
 
@@ -59,67 +116,475 @@ func nud(kind lexer.TokenKind, bp binding_power, nud_fn nud_handler) {
 	nud_lu[kind] = nud_fn
 }
 
+func postfix(kind lexer.TokenKind, bp binding_power, postfix_fn postfix_handler) {
+	postfix_bp_lu[kind] = bp
+	postfix_lu[kind] = postfix_fn
+}
+
 func createTokenLookups() {
 	// Additive & Multiplicitave
-	led(lexer.PLUS, additive, parse_binary_expr)
-	led(lexer.DASH, additive, parse_binary_expr)
-	led(lexer.SLASH, multiplicative, parse_binary_expr)
-	led(lexer.STAR, multiplicative, parse_binary_expr)
-	led(lexer.PERCENT, multiplicative, parse_binary_expr)
-	led(lexer.ROOT, exponential, parse_binary_expr)
-	led(lexer.HAT, exponential, parse_binary_expr)
-	led(lexer.LOG, exponential, parse_binary_expr)
+	led(lexer.PLUS, additive, left_assoc, parse_binary_expr)
+	led(lexer.DASH, additive, left_assoc, parse_binary_expr)
+	led(lexer.SLASH, multiplicative, left_assoc, parse_binary_expr)
+	led(lexer.STAR, multiplicative, left_assoc, parse_binary_expr)
+	led(lexer.PERCENT, multiplicative, left_assoc, parse_binary_expr)
+	led(lexer.ROOT, exponential, left_assoc, parse_binary_expr)
+	led(lexer.HAT, exponential, right_assoc, parse_binary_expr)
+	led(lexer.LOG, exponential, left_assoc, parse_binary_expr)
+
+	// Bitwise
+	led(lexer.PIPE, bitwise_or, left_assoc, parse_binary_expr)
+	led(lexer.XOR, bitwise_xor, left_assoc, parse_binary_expr)
+	led(lexer.AMPERSAND, bitwise_and, left_assoc, parse_binary_expr)
+	led(lexer.SHIFT_LEFT, shift, left_assoc, parse_binary_expr)
+	led(lexer.SHIFT_RIGHT, shift, left_assoc, parse_binary_expr)
+
+	// Intervals
+	led(lexer.PLUS_MINUS, tolerance, left_assoc, parse_binary_expr)
+	nud(lexer.OPEN_BRACKET, default_bp, parse_interval_expr)
+
+	// Units
+	led(lexer.TO, conversion, left_assoc, parse_conversion_expr)
+
+	// Assignment
+	led(lexer.ASSIGNMENT, assignment, right_assoc, parse_assignment_expr)
 
 	// Literals & Symbols
 	nud(lexer.NUMBER, primary, parse_primary_expr)
+	nud(lexer.IDENTIFIER, primary, parse_identifier_expr)
+	nud(lexer.DATE, primary, parse_date_expr)
+	nud(lexer.DURATION, primary, parse_duration_expr)
 
 	// Unary Operators
 	nud(lexer.DASH, additive, parse_unary_expr)
+	nud(lexer.TILDE, unary, parse_unary_expr)
+	nud(lexer.SQUARE_ROOT, unary, parse_square_root_expr)
 
 	// Grouping Expr
 	nud(lexer.OPEN_PAREN, default_bp, parse_grouping_expr)
+
+	// Postfix Operators
+	postfix(lexer.BANG, postfix_bp, parse_postfix_expr)
+	postfix(lexer.PERCENT, postfix_bp, parse_postfix_expr)
+	postfix(lexer.DEGREE, postfix_bp, parse_postfix_expr)
+	postfix(lexer.SUPERSCRIPT, postfix_bp, parse_superscript_expr)
+
+	// Implicit Multiplication
+	// Registered after the nuds above so these binding powers are the ones
+	// the parse loop sees for an operand that directly follows another one.
+	for _, kind := range operand_starts {
+		led(kind, multiplicative, left_assoc, parse_implicit_multiplication_expr)
+	}
+}
+
+// right_bp returns the binding power the right-hand operand of the infix operator
+// kind is parsed with. A left-associative operator stops its operand at the next
+// operator of the same binding power, a right-associative one lets the operand
+// take it.
+func right_bp(kind lexer.TokenKind) binding_power {
+	if assoc_lu[kind] == right_assoc {
+		return bp_lu[kind] - 1
+	}
+	return bp_lu[kind]
 }
 
 // parse_primary_expr parses a primary expression.
 // A primary expression can be a literal, identifier, or any expression that doesn't require further operator precedence handling.
-func parse_primary_expr(p *parser) Expr {
-	number, _ := strconv.ParseFloat(p.advance().Value, 64)
-	return NumberExpr{
-		Value: number,
+// A number directly followed by a unit is a quantity that binds tighter than
+// any operator, so "5 km / 2 h" divides 5 km by 2 h.
+func parse_primary_expr(p *parser) (Expr, error) {
+	token := p.advance()
+	number, err := ParseNumber(token.Value)
+	// A literal beyond the range of float64 is kept for the evaluators that
+	// read Literal, such as the Big backend.
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return nil, &InvalidNumberError{Literal: token.Value, Span: token.Span(), Err: err}
+	}
+	expr := NumberExpr{
+		Value:   number,
+		Literal: token.Value,
+		Err:     err,
+		Span:    token.Span(),
+	}
+
+	if p.unit_at(p.pos) {
+		return parse_quantity_expr(p, expr, token.Start), nil
+	}
+	return expr, nil
+}
+
+// parse_date_expr parses a date literal, as in "2026-03-01".
+func parse_date_expr(p *parser) (Expr, error) {
+	token := p.advance()
+	date, clock, err := ParseDate(token.Value)
+	if err != nil {
+		return nil, &InvalidDateError{Literal: token.Value, Span: token.Span(), Err: err}
+	}
+	return DateExpr{Value: date, Clock: clock, Span: token.Span()}, nil
+}
+
+// parse_duration_expr parses a duration literal, as in "3h 45m".
+func parse_duration_expr(p *parser) (Expr, error) {
+	token := p.advance()
+	seconds, err := ParseDuration(token.Value)
+	if err != nil {
+		return nil, &InvalidNumberError{Literal: token.Value, Span: token.Span(), Err: err}
+	}
+	return DurationExpr{Seconds: seconds, Literal: token.Value, Span: token.Span()}, nil
+}
+
+// parse_identifier_expr parses a reference to a variable, or a function call
+// when the name is a builtin followed by an opening parenthesis.
+// Any other name followed by a parenthesis is left to implicit multiplication.
+func parse_identifier_expr(p *parser) (Expr, error) {
+	token := p.advance()
+	if p.current().Kind == lexer.OPEN_PAREN && (IsFunction(token.Value) || !p.names_value(token.Value)) {
+		return parse_call_expr(p, token)
+	}
+	if token.Value == ImaginaryUnit {
+		return ImaginaryExpr{Span: token.Span()}, nil
+	}
+
+	return IdentifierExpr{
+		Name: token.Value,
+		Span: token.Span(),
+	}, nil
+}
+
+// names_value reports whether name stands for a value, so that a parenthesis
+// after it is multiplied with it, as in "a(b)", rather than called: a
+// variable of the parser's Env, a constant, a unit or the imaginary unit.
+func (p *parser) names_value(name string) bool {
+	if _, exists := LookupConstant(name); exists || name == ImaginaryUnit || units.IsUnit(name) {
+		return true
+	}
+	if p.env == nil {
+		return false
+	}
+	_, exists := p.env.Get(name)
+	return exists
+}
+
+// parse_call_expr parses the parenthesised, comma separated argument list of a call
+// to the function named by the already consumed token name.
+// The function must be a builtin and accept the number of arguments given.
+func parse_call_expr(p *parser, name lexer.Token) (Expr, error) {
+	fn, exists := builtins[name.Value]
+	if !exists {
+		return nil, &UnknownFunctionError{Name: name.Value, Span: name.Span()}
+	}
+
+	open := p.advance()
+	args := make([]Expr, 0)
+
+	for p.current().Kind != lexer.CLOSE_PAREN {
+		if len(args) > 0 {
+			if _, err := p.expect(lexer.COMMA); err != nil {
+				return nil, call_error(p, open, err)
+			}
+			if token := p.current(); token.Kind == lexer.CLOSE_PAREN {
+				return nil, &MissingOperandError{Token: token, Offset: token.Start}
+			}
+		}
+
+		arg, err := parse_expr(p, default_bp)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.advance()
+
+	span := lexer.Span{Start: name.Start, End: p.previous().End}
+	if !fn.accepts(len(args)) {
+		return nil, &ArityError{Name: name.Value, Got: len(args), Min: fn.minArgs, Max: fn.maxArgs, Span: span}
 	}
+
+	return CallExpr{
+		Name: name.Value,
+		Args: args,
+		Span: span,
+	}, nil
+}
+
+// call_error turns a missing separator at the end of the input into an
+// *UnbalancedParenError on the opening parenthesis or bracket.
+func call_error(p *parser, open lexer.Token, err error) error {
+	if p.current().Kind == lexer.END {
+		return &UnbalancedParenError{Token: open, Offset: open.Start}
+	}
+	return err
 }
 
 // parse_unary_expr parses a unary expression.
-// It handles expressions where a unary operator (such as '-') precedes an expression.
-func parse_unary_expr(p *parser) Expr {
+// It handles expressions where a unary operator (such as '-' or '~') precedes an expression.
+func parse_unary_expr(p *parser) (Expr, error) {
 	token := p.advance()
-	Member := parse_expr(p, unary)
+	Member, err := parse_expr(p, unary)
+	if err != nil {
+		return nil, err
+	}
 	return UnaryExpr{
 		Operator: token,
 		Member:   Member,
+		Span:     lexer.Span{Start: token.Start, End: p.previous().End},
+	}, nil
+}
+
+// parse_square_root_expr parses a square root written with the radical sign,
+// as in "√9" or "√(x+1)", as a call to sqrt. The sign binds like a unary
+// minus, so "√2^2" is 2 and "√2x" is a multiple of x.
+func parse_square_root_expr(p *parser) (Expr, error) {
+	token := p.advance()
+	radicand, err := parse_expr(p, unary)
+	if err != nil {
+		return nil, err
 	}
+	return CallExpr{
+		Name: "sqrt",
+		Args: []Expr{radicand},
+		Span: lexer.Span{Start: token.Start, End: p.previous().End},
+	}, nil
 }
 
 // parse_binary_expr parses a binary expression.
 // It takes the left-hand side expression and the current binding power, and returns a new expression
 // by handling the binary operator and the right-hand side expression.
-func parse_binary_expr(p *parser, left Expr, bp binding_power) Expr {
+func parse_binary_expr(p *parser, left Expr, bp binding_power) (Expr, error) {
+	start := p.leftStart
 	operatorToken := p.advance()
-	right := parse_expr(p, bp_lu[operatorToken.Kind])
+	right, err := parse_expr(p, right_bp(operatorToken.Kind))
+	if err != nil {
+		return nil, err
+	}
+
+	return BinaryExpr{
+		Left:     left,
+		Operator: operatorToken,
+		Right:    right,
+		Span:     lexer.Span{Start: start, End: p.previous().End},
+	}, nil
+}
+
+// parse_implicit_multiplication_expr parses an operand written right after another one,
+// as in "2(3+4)", "3pi" or "(a+b)(a-b)", as a multiplication.
+// A unit written right after an operand, as in "x km/h", makes a QuantityExpr instead.
+// The resulting BinaryExpr is marked Implicit and carries a zero-width "*"
+// operator at the start of the right-hand operand. Two adjacent number
+// literals such as "2 3" are never multiplied, see parser.binds.
+func parse_implicit_multiplication_expr(p *parser, left Expr, bp binding_power) (Expr, error) {
+	start := p.leftStart
+	token := p.current()
+	if p.unit_at(p.pos) {
+		return parse_quantity_expr(p, left, start), nil
+	}
+
+	operatorToken := lexer.Token{Kind: lexer.STAR, Value: "*", Start: token.Start, End: token.Start}
+	right, err := parse_expr(p, right_bp(token.Kind))
+	if err != nil {
+		return nil, err
+	}
 
 	return BinaryExpr{
 		Left:     left,
 		Operator: operatorToken,
 		Right:    right,
+		Implicit: true,
+		Span:     lexer.Span{Start: start, End: p.previous().End},
+	}, nil
+}
+
+// parse_postfix_expr parses a postfix operator applied to the operand before it,
+// as in "5!", "10%" or "90°".
+func parse_postfix_expr(p *parser, left Expr) (Expr, error) {
+	start := p.leftStart
+	operatorToken := p.advance()
+
+	return PostfixExpr{
+		Operator: operatorToken,
+		Member:   left,
+		Span:     lexer.Span{Start: start, End: operatorToken.End},
+	}, nil
+}
+
+// parse_superscript_expr parses an exponent written in superscript, as in
+// "x²" or "10⁻³", as a power. The exponent binds like a postfix operator, so
+// "x²!" is the factorial of x² and "2x²" is twice x².
+func parse_superscript_expr(p *parser, left Expr) (Expr, error) {
+	start := p.leftStart
+	token := p.advance()
+
+	digits := strings.TrimPrefix(token.Value, "-")
+	value, err := ParseNumber(digits)
+	if err != nil {
+		return nil, &InvalidNumberError{Literal: token.Value, Span: token.Span(), Err: err}
+	}
+	var exponent Expr = NumberExpr{Value: value, Literal: digits, Span: token.Span()}
+	if digits != token.Value {
+		minus := lexer.Token{Kind: lexer.DASH, Value: "-", Start: token.Start, End: token.Start}
+		exponent = UnaryExpr{Operator: minus, Member: exponent, Span: token.Span()}
+	}
+
+	return BinaryExpr{
+		Left:     left,
+		Operator: lexer.Token{Kind: lexer.HAT, Value: "^", Start: token.Start, End: token.Start},
+		Right:    exponent,
+		Span:     lexer.Span{Start: start, End: token.End},
+	}, nil
+}
+
+// parse_assignment_expr parses an assignment such as "rate = 0.07".
+// The left-hand side must be a variable name. Assignment is registered as
+// right-associative, so "a = b = 1" assigns 1 to both variables.
+func parse_assignment_expr(p *parser, left Expr, bp binding_power) (Expr, error) {
+	start := p.leftStart
+	operatorToken := p.advance()
+
+	target, isIdentifier := left.(IdentifierExpr)
+	if !isIdentifier {
+		return nil, &InvalidAssignmentError{Token: operatorToken, Offset: operatorToken.Start}
+	}
+
+	value, err := parse_expr(p, right_bp(operatorToken.Kind))
+	if err != nil {
+		return nil, err
 	}
+
+	return AssignmentExpr{
+		Target: target,
+		Value:  value,
+		Span:   lexer.Span{Start: start, End: p.previous().End},
+	}, nil
 }
 
 // parse_grouping_expr parses a grouping expression.
 // Grouping expressions, typically enclosed in parentheses, are used to explicitly specify the order of evaluation.
-func parse_grouping_expr(p *parser) Expr {
-	p.expect(lexer.OPEN_PAREN)
-	expr := parse_expr(p, default_bp)
-	p.expect(lexer.CLOSE_PAREN)
+// A missing closing parenthesis is reported as an *UnbalancedParenError on the opening one.
+func parse_grouping_expr(p *parser) (Expr, error) {
+	open, err := p.expect(lexer.OPEN_PAREN)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := parse_expr(p, default_bp)
+	if err != nil {
+		return nil, err
+	}
+	if p.current().Kind == lexer.END {
+		return nil, &UnbalancedParenError{Token: open, Offset: open.Start}
+	}
+	if _, err := p.expect(lexer.CLOSE_PAREN); err != nil {
+		return nil, err
+	}
+
+	return expr, nil
+}
+
+// parse_interval_expr parses an interval written with its bounds, as in "[9.8, 10.2]".
+// A missing closing bracket is reported as an *UnbalancedParenError on the opening one.
+func parse_interval_expr(p *parser) (Expr, error) {
+	open, err := p.expect(lexer.OPEN_BRACKET)
+	if err != nil {
+		return nil, err
+	}
+
+	lo, err := parse_expr(p, default_bp)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(lexer.COMMA); err != nil {
+		return nil, call_error(p, open, err)
+	}
+	hi, err := parse_expr(p, default_bp)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(lexer.CLOSE_BRACKET); err != nil {
+		return nil, call_error(p, open, err)
+	}
+
+	return IntervalExpr{
+		Lo:   lo,
+		Hi:   hi,
+		Span: lexer.Span{Start: open.Start, End: p.previous().End},
+	}, nil
+}
+
+// unit_at reports whether the token at pos is the name of a unit.
+// A name that is also a function, like "min", is the function when a
+// parenthesis follows it.
+func (p *parser) unit_at(pos int) bool {
+	token := p.tokens[pos]
+	if token.Kind != lexer.IDENTIFIER || !units.IsUnit(token.Value) {
+		return false
+	}
+	return p.tokens[pos+1].Kind != lexer.OPEN_PAREN || !IsFunction(token.Value)
+}
+
+// parse_quantity_expr parses the unit written after value, as in "5 km/h".
+func parse_quantity_expr(p *parser, value Expr, start int) Expr {
+	unit := parse_unit(p)
+	return QuantityExpr{
+		Value: value,
+		Unit:  unit,
+		Span:  lexer.Span{Start: start, End: p.previous().End},
+	}
+}
+
+// parse_unit parses a unit starting at the current name and returns it as text.
+// The unit continues with integer powers, as in "m^2", "m²" or "s^-1", and with
+// further units multiplied, divided or written next to it, as in "km/h" or
+// "N m". A "*" or "/" followed by anything but a unit ends it, so in
+// "5 km / 2 h" the unit of 5 is km.
+func parse_unit(p *parser) string {
+	var unit strings.Builder
+	for {
+		unit.WriteString(p.advance().Value)
+
+		if p.current().Kind == lexer.SUPERSCRIPT {
+			unit.WriteString("^" + p.advance().Value)
+		} else if p.current().Kind == lexer.HAT {
+			exponent := p.pos + 1
+			if p.tokens[exponent].Kind == lexer.DASH {
+				exponent++
+			}
+			if p.tokens[exponent].Kind == lexer.NUMBER {
+				for p.pos <= exponent {
+					unit.WriteString(p.advance().Value)
+				}
+			}
+		}
+
+		switch kind := p.current().Kind; {
+		case (kind == lexer.STAR || kind == lexer.SLASH) && p.unit_at(p.pos+1):
+			unit.WriteString(p.advance().Value)
+		case p.unit_at(p.pos):
+			unit.WriteString("*")
+		default:
+			return unit.String()
+		}
+	}
+}
+
+// parse_conversion_expr parses the conversion of left to the unit written
+// after "to", as in "5 km/h to mph", or to a base, as in "255 to hex".
+func parse_conversion_expr(p *parser, left Expr, bp binding_power) (Expr, error) {
+	start := p.leftStart
+	p.advance()
+
+	if p.radix_at(p.pos) {
+		return parse_radix_expr(p, left, start)
+	}
+
+	if token := p.current(); token.Kind != lexer.IDENTIFIER {
+		if token.Kind == lexer.END {
+			return nil, &MissingOperandError{Token: token, Offset: token.Start}
+		}
+		return nil, &UnexpectedTokenError{Token: token, Offset: token.Start, Expected: []lexer.TokenKind{lexer.IDENTIFIER}}
+	}
 
-	return expr
+	return ConversionExpr{
+		Value: left,
+		Unit:  parse_unit(p),
+		Span:  lexer.Span{Start: start, End: p.previous().End},
+	}, nil
 }
@@ -0,0 +1,39 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseNumber converts the text of a NUMBER token to its value.
+// It understands every literal form the lexer accepts: decimals with optional
+// exponent, the 0x, 0b and 0o integer prefixes and "_" digit separators.
+func ParseNumber(literal string) (float64, error) {
+	digits, base := SplitNumber(literal)
+	if base == 10 {
+		return strconv.ParseFloat(digits, 64)
+	}
+
+	value, err := strconv.ParseUint(digits, base, 64)
+	return float64(value), err
+}
+
+// SplitNumber removes the digit separators and base prefix from a NUMBER literal,
+// returning the remaining digits and the base they are written in.
+func SplitNumber(literal string) (string, int) {
+	digits := strings.ReplaceAll(literal, "_", "")
+
+	if len(digits) > 2 && digits[0] == '0' {
+		switch digits[1] {
+		case 'x', 'X':
+			return digits[2:], 16
+		case 'b', 'B':
+			return digits[2:], 2
+		case 'o', 'O':
+			return digits[2:], 8
+		}
+	}
+	return digits, 10
+}
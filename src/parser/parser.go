@@ -4,33 +4,41 @@ package parser
 
 import (
 	"calculator/src/lexer"
-	"fmt"
 )
 
 type parser struct {
 	tokens []lexer.Token
 	pos    int
+	// leftStart is the source offset where the left operand handed to the
+	// running led handler begins, including any opening parenthesis.
+	leftStart int
+	// env holds the variables the expression may use, or is nil.
+	env *Env
 }
 
 func (p *parser) current() lexer.Token {
 	return p.tokens[p.pos]
 }
+func (p *parser) previous() lexer.Token {
+	return p.tokens[p.pos-1]
+}
 func (p *parser) advance() lexer.Token {
 	next := p.tokens[p.pos]
 	p.pos++
 	return next
 }
-func (p *parser) expect(expectedKind lexer.TokenKind) lexer.Token {
+func (p *parser) expect(expectedKind lexer.TokenKind) (lexer.Token, error) {
 	token := p.current()
 
 	if token.Kind != expectedKind {
-
-		err := fmt.Sprintf("Expected %s but recieved %s instead\n", lexer.TokenKindString(expectedKind), token.KindString())
-
-		panic(err)
+		return token, &UnexpectedTokenError{
+			Token:    token,
+			Offset:   token.Start,
+			Expected: []lexer.TokenKind{expectedKind},
+		}
 	}
 
-	return p.advance()
+	return p.advance(), nil
 }
 func createParser(tokens []lexer.Token) *parser {
 	createTokenLookups()
@@ -42,20 +50,52 @@ func createParser(tokens []lexer.Token) *parser {
 	return p
 }
 
+// Options configures how ParseWithOptions reads its input.
+type Options struct {
+	// Lenient accepts input that continues after a complete expression and
+	// returns the expression parsed from that prefix, ignoring the rest.
+	// By default the whole input must form a single expression.
+	Lenient bool
+	// Locale is how numbers and argument separators are written in the input.
+	Locale lexer.Locale
+	// Env holds the variables defined so far. A name followed by "(" is
+	// multiplied with the parenthesis when it is one of them, as in "a(b)",
+	// and is otherwise an unknown function unless it names a constant or a
+	// unit.
+	Env *Env
+}
+
 // Parse takes a source string representing an expression, tokenizes it,
 // and returns the parsed expression as an Expr.
-// It uses panic recovery to catch and report parsing errors.
-func Parse(source string) Expr {
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Printf("\n\nerror parsing equation\n\n")
-		}
-	}()
-	tokens := lexer.Tokenize(source)
+// The whole source must form one expression.
+// Syntax problems are reported as one of *lexer.UnknownCharacterError,
+// *UnexpectedTokenError, *UnbalancedParenError, *MissingOperandError
+// or *TrailingTokenError.
+func Parse(source string) (Expr, error) {
+	return ParseWithOptions(source, Options{})
+}
+
+// ParseWithOptions works like Parse with the behaviour adjusted by opts.
+func ParseWithOptions(source string, opts Options) (Expr, error) {
+	tokens, err := lexer.TokenizeLocale(source, opts.Locale)
+	if err != nil {
+		return nil, err
+	}
 	p := createParser(tokens)
+	p.env = opts.Env
+
+	expr, err := parse_expr(p, default_bp)
+	if err != nil {
+		return nil, err
+	}
 
-	expr := parse_expr(p, default_bp)
-	return expr
+	if token := p.current(); token.Kind != lexer.END && !opts.Lenient {
+		if token.Kind == lexer.CLOSE_PAREN {
+			return nil, &UnbalancedParenError{Token: token, Offset: token.Start}
+		}
+		return nil, &TrailingTokenError{Token: token, Offset: token.Start}
+	}
+	return expr, nil
 }
 
 // parse_expr parses an expression using a Pratt parser.
@@ -64,26 +104,97 @@ func Parse(source string) Expr {
 // to parse the left-hand side of the expression. Then, while the binding power of the
 // current token is greater than the provided binding power, it uses a left denotation (led)
 // handler to parse the operator and its right-hand expression.
-func parse_expr(p *parser, bp binding_power) Expr {
-	tokenKind := p.current().Kind
-	nud_fn, exists := nud_lu[tokenKind]
+func parse_expr(p *parser, bp binding_power) (Expr, error) {
+	token := p.current()
+	start := token.Start
+	nud_fn, exists := nud_lu[token.Kind]
 
 	if !exists {
-		panic(fmt.Sprintf("NUD Handler expected for token %s\n", lexer.TokenKindString(tokenKind)))
+		return nil, nud_error(token)
+	}
+
+	left, err := nud_fn(p)
+	if err != nil {
+		return nil, err
 	}
 
-	left := nud_fn(p)
+	for p.binds(bp) {
+		token = p.current()
+		p.leftStart = start
+
+		if p.is_postfix() {
+			left, err = postfix_lu[token.Kind](p, left)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
 
-	for bp_lu[p.current().Kind] > bp {
-		tokenKind = p.current().Kind
-		led_fn, exists := led_lu[tokenKind]
+		led_fn, exists := led_lu[token.Kind]
 
 		if !exists {
-			panic(fmt.Sprintf("LED Handler expected for token %s\n", lexer.TokenKindString(tokenKind)))
+			return nil, &UnexpectedTokenError{Token: token, Offset: token.Start}
+		}
+
+		left, err = led_fn(p, left, bp)
+		if err != nil {
+			return nil, err
 		}
+	}
+
+	return left, nil
+}
+
+// nud_error builds the error for a token that cannot start an expression.
+func nud_error(token lexer.Token) error {
+	switch {
+	case token.Kind == lexer.CLOSE_PAREN:
+		return &UnbalancedParenError{Token: token, Offset: token.Start}
+	case token.Kind == lexer.END:
+		return &MissingOperandError{Token: token, Offset: token.Start}
+	}
+
+	if _, isOperator := led_lu[token.Kind]; isOperator {
+		return &MissingOperandError{Token: token, Offset: token.Start}
+	}
+	return &UnexpectedTokenError{Token: token, Offset: token.Start}
+}
 
-		left = led_fn(p, left, bp)
+// binds reports whether the current token continues the expression on its left,
+// that is whether its binding power is above bp. A number right after another
+// number never does, so "2 3" ends after the 2 instead of being multiplied.
+func (p *parser) binds(bp binding_power) bool {
+	token := p.current()
+	if token.Kind == lexer.NUMBER && p.previous().Kind == lexer.NUMBER {
+		return false
 	}
+	if p.is_postfix() {
+		return postfix_bp_lu[token.Kind] > bp
+	}
+	return bp_lu[token.Kind] > bp
+}
 
-	return left
+// is_postfix reports whether the current token acts as a postfix operator.
+// A token registered as both postfix and infix, like "%", is postfix unless
+// something that can start an operand follows it, including a prefix operator:
+// "10 % 3", "10 % ~3" and "10 % -3" are remainders while "200 + 10%" is a
+// percentage. A following "-" is also infix, so it is only read as a sign when
+// it is set apart from the "%" and written against its operand: "200 + 10% - 5"
+// subtracts 5 from the percentage.
+func (p *parser) is_postfix() bool {
+	kind := p.current().Kind
+	if _, exists := postfix_lu[kind]; !exists {
+		return false
+	}
+	if _, exists := led_lu[kind]; !exists {
+		return true
+	}
+	next := p.tokens[p.pos+1]
+	if _, operand := nud_lu[next.Kind]; !operand {
+		return true
+	}
+	if _, infix := led_lu[next.Kind]; infix && !lexer.IsOneOf(next.Kind, operand_starts) {
+		return next.Start == p.current().End || p.tokens[p.pos+2].Start != next.End
+	}
+	return false
 }
@@ -3,7 +3,9 @@
 package parser_test
 
 import (
+	"calculator/src/lexer"
 	"calculator/src/parser"
+	"errors"
 	"fmt"
 	"math"
 	"testing"
@@ -53,6 +55,89 @@ var equations = []EquationResult{
 	{"8 r3 ", 2},
 	{"100 l 10", 2},
 
+	// Numeric literals
+	{"1e-9", 1e-9},
+	{"6.02E23", 6.02e23},
+	{"2.5e+3", 2500},
+	{"1e-12 * 3", 3e-12},
+	{"6.674e-11*2", 1.3348e-10},
+	{"1.5e-20 / 3e-5", 5e-16},
+	{"2.5e-15 * 4e3", 1e-11},
+	{".5", 0.5},
+	{"5.", 5},
+	{".5 + 5.", 5.5},
+	{"0x1F", 31},
+	{"0XfF", 255},
+	{"0b1010", 10},
+	{"0o17", 15},
+	{"1_000_000", 1000000},
+	{"0xFF_FF", 65535},
+	{"1_0.2_5", 10.25},
+
+	// Built-in functions
+	{"sqrt(16)", 4},
+	{"2 * sqrt(9) + 1", 7},
+	{"abs(-3)", 3},
+	{"sqrt(abs(-16)) r2", 2},
+	{"min(4, 2)", 2},
+	{"max(1, 5, 3)", 5},
+	{"max(2)", 2},
+	{"floor(2.7) + ceil(2.2)", 5},
+	{"round(2.5)", 3},
+	{"round(-2.5)", -3},
+	{"round(3.14159, 2)", 3.14},
+	{"ln(exp(2))", 2},
+	{"exp(0)", 1},
+	{"sin(0)", 0},
+	{"cos(0)", 1},
+	{"tan(0)", 0},
+
+	// Implicit multiplication
+	{"2(3 + 4)", 14},
+	{"(1 + 2)(3 + 4)", 21},
+	{"(1 + 2)3", 9},
+	{"2(3)(4)", 24},
+	{"-2(3)", -6},
+	{"2(3)^2", 18},
+	{"6 / 2(1 + 2)", 9},
+	{"2 sqrt(9)", 6},
+	{"2 max(1, 4) 3", 24},
+
+	// Postfix operators
+	{"5!", 120},
+	{"0!", 1},
+	{"3!!", 720},
+	{"2 ^ 3!", 64},
+	{"-3!", -6},
+	{"2(3)!", 12},
+	{"(1 + 2)!", 6},
+	{"0.5! * 2 / sqrt(pi)", 1},
+	{"10%", 0.1},
+	{"200 + 10%", 220},
+	{"200 - 10%", 180},
+	{"200 + 10% - 5", 215},
+	{"50 * 10%", 5},
+	{"50 / 10%", 500},
+	{"10 % 4", 2},
+	{"10 % -3", 1},
+	{"10 % ~3", 2},
+	{"max(10%, 5%)", 0.1},
+	{"180°", math.Pi},
+	{"sin(90°)", 1},
+
+	// Constants
+	{"pi", math.Pi},
+	{"cos(pi)", -1},
+	{"ln(e)", 1},
+	{"g0", 9.80665},
+	{"c", 299792458},
+	{"tau - pi - pi", 0},
+	{"h/(2*pi)", 1.05457181764616e-34},
+	{"kB*300", 4.141947e-21},
+	{"me*c^2", 8.18710577682389e-14},
+	{"qe/1", 1.602176634e-19},
+	{"G*1", 6.6743e-11},
+
 	// Complex expressions
 	{"2 + 3 * 4 - 5 / 2", 11.5},
 	{"(2 + 3) * (4 - 5) / 2", -2.5},
@@ -72,18 +157,620 @@ func TestEquationParser(t *testing.T) {
 	for i, eq := range equations {
 		fmt.Printf("Equation %d: %s\n", i+1, eq.eq)
 		start := time.Now()
-		ast := parser.Parse(eq.eq)
+		ast, err := parser.Parse(eq.eq)
 		duration := time.Since(start)
 
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected error: %v", eq.eq, err)
+			continue
+		}
+
+		result, err := ast.Eval(parser.NewEnv())
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+			continue
+		}
+
 		fmt.Printf("Result Equation: %s\n", ast.ToString())
-		fmt.Printf("Result: %g\n", ast.Eval())
+		fmt.Printf("Result: %g\n", result)
 		fmt.Printf("Duration: %v\n", duration)
 
 		fmt.Println()
 
-		if eq.expextedResult != ast.Eval() {
-			t.Errorf("In Equation %s\n Expected result is %g but the result was %g", eq.eq, eq.expextedResult, ast.Eval())
+		if eq.expextedResult != result {
+			t.Errorf("In Equation %s\n Expected result is %g but the result was %g", eq.eq, eq.expextedResult, result)
 			litter.Dump(ast)
 		}
 	}
 }
+
+type ErrorResult struct {
+	eq             string
+	expectedErr    error
+	expectedOffset int
+}
+
+var invalidEquations = []ErrorResult{
+	{"2 +", &parser.MissingOperandError{}, 3},
+	{"2 + * 3", &parser.MissingOperandError{}, 4},
+	{"* 3", &parser.MissingOperandError{}, 0},
+	{"(2 + 3", &parser.UnbalancedParenError{}, 0},
+	{"2 * ((1 + 3)", &parser.UnbalancedParenError{}, 4},
+	{")", &parser.UnbalancedParenError{}, 0},
+	{"2 + )", &parser.UnbalancedParenError{}, 4},
+	{"2 3", &parser.TrailingTokenError{}, 2},
+	{"(1+2))", &parser.UnbalancedParenError{}, 5},
+	{"1+2)4", &parser.UnbalancedParenError{}, 3},
+	{"2 + 3 4", &parser.TrailingTokenError{}, 6},
+	{"x = 1 2", &parser.TrailingTokenError{}, 6},
+	{"& 3", &parser.MissingOperandError{}, 0},
+	{"1 << ", &parser.MissingOperandError{}, 5},
+	{"2 ~ 3", &parser.UnexpectedTokenError{}, 2},
+	{"2 + $", &lexer.UnknownCharacterError{}, 4},
+	{"2 = 3", &parser.InvalidAssignmentError{}, 2},
+	{"a + 1 = 3", &parser.InvalidAssignmentError{}, 6},
+	{"i = 3", &parser.InvalidAssignmentError{}, 2},
+	{"", &parser.MissingOperandError{}, 0},
+	{"(1 + 2) 3 4", &parser.TrailingTokenError{}, 10},
+	{"sqrt(1, 2)", &parser.ArityError{}, 0},
+	{"2 * foo(1)", &parser.UnknownFunctionError{}, 4},
+	{"a(b)", &parser.UnknownFunctionError{}, 0},
+	{"1 + max()", &parser.ArityError{}, 4},
+	{"sqrt(2", &parser.UnbalancedParenError{}, 4},
+	{"[1, 2", &parser.UnbalancedParenError{}, 0},
+	{"[1 2]", &parser.UnexpectedTokenError{}, 3},
+	{"min(1,)", &parser.MissingOperandError{}, 6},
+	{"min(1 2)", &parser.UnexpectedTokenError{}, 6},
+	{"5 km to", &parser.MissingOperandError{}, 7},
+	{"5 km to 3", &parser.UnexpectedTokenError{}, 8},
+	{"1 + 2026-02-30", &parser.InvalidDateError{}, 4},
+	{"2026-13-01", &parser.InvalidDateError{}, 0},
+	{"2026-03-01 24:00", &parser.InvalidDateError{}, 0},
+}
+
+// errorOffset returns the source offset carried by a syntax error.
+func errorOffset(err error) int {
+	switch e := err.(type) {
+	case *lexer.UnknownCharacterError:
+		return e.Offset
+	case *parser.MissingOperandError:
+		return e.Offset
+	case *parser.UnbalancedParenError:
+		return e.Offset
+	case *parser.UnexpectedTokenError:
+		return e.Offset
+	case *parser.InvalidAssignmentError:
+		return e.Offset
+	case *parser.UnknownFunctionError:
+		return e.Span.Start
+	case *parser.ArityError:
+		return e.Span.Start
+	case *parser.InvalidNumberError:
+		return e.Span.Start
+	case *parser.InvalidDateError:
+		return e.Span.Start
+	case *parser.TrailingTokenError:
+		return e.Offset
+	}
+	return -1
+}
+
+func TestEquationParserErrors(t *testing.T) {
+	for _, eq := range invalidEquations {
+		ast, err := parser.Parse(eq.eq)
+		if err == nil {
+			t.Errorf("In Equation %q\n Expected an error but parsed %s", eq.eq, ast.ToString())
+			continue
+		}
+		fmt.Printf("Equation %q: %v\n", eq.eq, err)
+
+		offset := errorOffset(err)
+		if fmt.Sprintf("%T", err) != fmt.Sprintf("%T", eq.expectedErr) {
+			t.Errorf("In Equation %q\n Expected %T but got %T (%v)", eq.eq, eq.expectedErr, err, err)
+		} else if offset != eq.expectedOffset {
+			t.Errorf("In Equation %q\n Expected offset %d but got %d", eq.eq, eq.expectedOffset, offset)
+		}
+	}
+}
+
+func TestOutOfRangeLiterals(t *testing.T) {
+	cases := []ErrorResult{
+		{"2 * 1e400", &parser.InvalidNumberError{}, 4},
+		{"0x1_0000_0000_0000_0000", &parser.InvalidNumberError{}, 0},
+	}
+
+	for _, eq := range cases {
+		ast, err := parser.Parse(eq.eq)
+		if err != nil {
+			t.Errorf("In Equation %q\n Unexpected error: %v", eq.eq, err)
+			continue
+		}
+		_, err = ast.Eval(parser.NewEnv())
+		var invalid *parser.InvalidNumberError
+		if !errors.As(err, &invalid) {
+			t.Errorf("In Equation %q\n Expected %T but got %v", eq.eq, eq.expectedErr, err)
+		} else if invalid.Span.Start != eq.expectedOffset {
+			t.Errorf("In Equation %q\n Expected offset %d but got %d", eq.eq, eq.expectedOffset, invalid.Span.Start)
+		}
+	}
+}
+
+func TestExpressionSpans(t *testing.T) {
+	source := "(2 * (3 + 45)) - -(7)"
+	ast, err := parser.Parse(source)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	sub := ast.(parser.BinaryExpr)
+	mul := sub.Left.(parser.BinaryExpr)
+	add := mul.Right.(parser.BinaryExpr)
+	neg := sub.Right.(parser.UnaryExpr)
+
+	spans := map[string]parser.Expr{
+		"(2 * (3 + 45)) - -(7)": sub,
+		"2 * (3 + 45)":          mul,
+		"3 + 45":                add,
+		"45":                    add.Right,
+		"-(7)":                  neg,
+	}
+	for text, expr := range spans {
+		span := expr.Position()
+		if source[span.Start:span.End] != text {
+			t.Errorf("Expected span of %s to cover %q but covered %q", expr.ToString(), text, source[span.Start:span.End])
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	env := parser.NewEnv()
+	session := []EquationResult{
+		{"rate = 0.07", 0.07},
+		{"price = 200", 200},
+		{"price * rate", 14},
+		{"total = price + price * rate", 214},
+		{"a = b = 3", 3},
+		{"a * b", 9},
+		{"rate = rate * 2", 0.14},
+		{"9r2 + 100 l10", 5},
+		{"e = 2", 2},
+		{"e + pi - pi", 2},
+		{"s = 4", 4},
+		{"2 * s", 8},
+		{"a(b + 1)", 12},
+	}
+
+	for _, eq := range session {
+		ast, err := parser.ParseWithOptions(eq.eq, parser.Options{Env: env})
+		if err != nil {
+			t.Fatalf("In Equation %s\n Unexpected error: %v", eq.eq, err)
+		}
+		result, err := ast.Eval(env)
+		if err != nil {
+			t.Fatalf("In Equation %s\n Unexpected evaluation error: %v", eq.eq, err)
+		}
+		if math.Abs(result-eq.expextedResult) > 1e-12 {
+			t.Errorf("In Equation %s\n Expected result is %g but the result was %g", eq.eq, eq.expextedResult, result)
+		}
+	}
+
+	ast, _ := parser.Parse("2 * tax")
+	_, err := ast.Eval(env)
+	var undefined *parser.UndefinedVariableError
+	if !errors.As(err, &undefined) || undefined.Name != "tax" || undefined.Span.Start != 4 {
+		t.Errorf("Expected undefined variable tax at offset 4 but had %v", err)
+	}
+
+	_, err = parser.ParseWithOptions("2 * foo(1)", parser.Options{Env: env})
+	var unknown *parser.UnknownFunctionError
+	if !errors.As(err, &unknown) || unknown.Name != "foo" {
+		t.Errorf("Expected unknown function foo but had %v", err)
+	}
+}
+
+func TestVariablesDoNotShadowUnits(t *testing.T) {
+	env := parser.NewEnv()
+	env.Set("m", 3)
+	env.Set("s", 4)
+	cases := map[string]string{
+		"2 m":                "(2 m)",
+		"2 m^2":              "(2 m^2)",
+		"2m²":                "(2 m^2)",
+		"1 km + 500 m to mi": "(((1 km) + (500 m)) to mi)",
+		"1 m + 1 s":          "((1 m) + (1 s))",
+		"2 * m":              "(2 * m)",
+		"m(s)":               "(m * s)",
+	}
+
+	for source, expected := range cases {
+		ast, err := parser.ParseWithOptions(source, parser.Options{Env: env})
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected error: %v", source, err)
+			continue
+		}
+		if ast.ToString() != expected {
+			t.Errorf("In Equation %s\n Expected %s but had %s", source, expected, ast.ToString())
+		}
+	}
+
+	ast, _ := parser.ParseWithOptions("1 m + 1 s", parser.Options{Env: env})
+	var unitErr *parser.UnitError
+	if _, err := ast.Eval(env); !errors.As(err, &unitErr) {
+		t.Errorf("Expected 1 m + 1 s to need units rather than use m and s but had %v", err)
+	}
+}
+
+func TestImplicitMultiplicationToString(t *testing.T) {
+	cases := map[string]string{
+		"2(3+4)":       "(2 * (3 + 4))",
+		"(a+b)(a-b)":   "((a + b) * (a - b))",
+		"3pi":          "(3 * pi)",
+		"2x^2":         "(2 * (x ^ 2))",
+		"2 sin(x) y":   "((2 * sin(x)) * y)",
+		"-2(x + 1)":    "((-2) * (x + 1))",
+		"rate = 2pi":   "(rate = (2 * pi))",
+		"(1)(2)(3)+4":  "(((1 * 2) * 3) + 4)",
+		"2 ^ 3!":       "(2 ^ (3!))",
+		"200 + 10%":    "(200 + (10%))",
+		"10 % 3":       "(10 % 3)",
+		"10 % -3":      "(10 % (-3))",
+		"a % -b":       "(a % (-b))",
+		"10 % ~3":      "(10 % (~3))",
+		"10 % √9":      "(10 % sqrt(9))",
+		"10% - 3":      "((10%) - 3)",
+		"10%-3":        "((10%) - 3)",
+		"2x%":          "(2 * (x%))",
+		"sin(30°)2":    "(sin((30°)) * 2)",
+		"3 + 4i":       "(3 + (4 * i))",
+		"2in":          "(2 in)",
+		"2 * 10 ± 0.2": "(2 * (10 ± 0.2))",
+		"2[1, 2]":      "(2 * [1, 2])",
+	}
+
+	for source, expected := range cases {
+		ast, err := parser.Parse(source)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected error: %v", source, err)
+			continue
+		}
+		if ast.ToString() != expected {
+			t.Errorf("In Equation %s\n Expected %s but had %s", source, expected, ast.ToString())
+		}
+	}
+
+	ast, _ := parser.Parse("2(3)")
+	if mul := ast.(parser.BinaryExpr); !mul.Implicit || mul.Operator.Start != 1 || mul.Operator.End != 1 {
+		t.Errorf("Expected an implicit zero-width operator at offset 1 but had %+v", mul.Operator)
+	}
+}
+
+func TestUnitsToString(t *testing.T) {
+	cases := map[string]string{
+		"5 km / 2 h":         "((5 km) / (2 h))",
+		"9.81 m/s^2":         "(9.81 m/s^2)",
+		"2 N m":              "(2 N*m)",
+		"3 kg*m*s^-2":        "(3 kg*m*s^-2)",
+		"x km/h":             "(x km/h)",
+		"(1 + 2) m":          "((1 + 2) m)",
+		"2 m * x":            "((2 m) * x)",
+		"2 min(1, 2)":        "(2 * min(1, 2))",
+		"5 km/h to mph":      "((5 km/h) to mph)",
+		"1 km + 500 m to mi": "(((1 km) + (500 m)) to mi)",
+		"d = 3 ft to m":      "(d = ((3 ft) to m))",
+	}
+
+	for source, expected := range cases {
+		ast, err := parser.Parse(source)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected error: %v", source, err)
+			continue
+		}
+		if ast.ToString() != expected {
+			t.Errorf("In Equation %s\n Expected %s but had %s", source, expected, ast.ToString())
+		}
+	}
+}
+
+func TestCalendarToString(t *testing.T) {
+	cases := map[string]string{
+		"2026-03-01 + 90 days":      "(2026-03-01 + (90 days))",
+		"2026-03-01T09:30 - today":  "(2026-03-01 09:30 - today)",
+		"2026-03-01 09:30:15":       "2026-03-01 09:30:15",
+		"3h 45m * 4":                "(3h 45m * 4)",
+		"-1d 12h":                   "(-1d 12h)",
+		"weekday(2027-01-01)":       "weekday(2027-01-01)",
+		"workday(2026-03-01, 10)":   "workday(2026-03-01, 10)",
+		"(2026-12-25 - today) to h": "((2026-12-25 - today) to h)",
+	}
+
+	for source, expected := range cases {
+		ast, err := parser.Parse(source)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected error: %v", source, err)
+			continue
+		}
+		if ast.ToString() != expected {
+			t.Errorf("In Equation %s\n Expected %s but had %s", source, expected, ast.ToString())
+		}
+	}
+
+	for _, source := range []string{"2026-03-01", "3h 45m"} {
+		ast, _ := parser.Parse(source)
+		var calendar *parser.CalendarError
+		if _, err := ast.Eval(parser.NewEnv()); !errors.As(err, &calendar) {
+			t.Errorf("In Equation %s\n Expected a CalendarError from Eval but had %v", source, err)
+		}
+	}
+}
+
+func TestRadixToString(t *testing.T) {
+	cases := []struct {
+		source   string
+		expected string
+		value    float64
+	}{
+		{"255 to hex", "(255 to hex)", 255},
+		{"0xff to bin", "(255 to bin)", 255},
+		{"100 to base 7", "(100 to base 7)", 100},
+		{"1 + 2 to base 16", "((1 + 2) to hex)", 3},
+		{"x = 8 to oct", "(x = (8 to oct))", 8},
+	}
+
+	for _, c := range cases {
+		ast, err := parser.Parse(c.source)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected error: %v", c.source, err)
+			continue
+		}
+		if ast.ToString() != c.expected {
+			t.Errorf("In Equation %s\n Expected %s but had %s", c.source, c.expected, ast.ToString())
+		}
+		if value, err := ast.Eval(parser.NewEnv()); err != nil || value != c.value {
+			t.Errorf("In Equation %s\n Expected %g but had %g, %v", c.source, c.value, value, err)
+		}
+	}
+
+	for _, source := range []string{"255 to base 1", "255 to base 37", "255 to base 2.5"} {
+		var invalid *parser.InvalidBaseError
+		if _, err := parser.Parse(source); !errors.As(err, &invalid) {
+			t.Errorf("In Equation %s\n Expected an InvalidBaseError but had %v", source, err)
+		}
+	}
+}
+
+func TestUnicodeRender(t *testing.T) {
+	cases := []struct {
+		source  string
+		ascii   string
+		unicode string
+		value   float64
+	}{
+		{"3 × 4 ÷ 2 − 1", "(((3 * 4) / 2) - 1)", "(((3 × 4) ÷ 2) − 1)", 5},
+		{"6·7", "(6 * 7)", "(6 × 7)", 42},
+		{"−2", "(-2)", "(−2)", -2},
+		{"√9 + 7", "(sqrt(9) + 7)", "(√9 + 7)", 10},
+		{"√(9 + 7)", "sqrt((9 + 7))", "√(9 + 7)", 4},
+		{"2√9", "(2 * sqrt(9))", "(2 × √9)", 6},
+		{"√2^2", "sqrt((2 ^ 2))", "√(2²)", 2},
+		{"3²", "(3 ^ 2)", "(3²)", 9},
+		{"2³ + 2", "((2 ^ 3) + 2)", "((2³) + 2)", 10},
+		{"2¹⁰", "(2 ^ 10)", "(2¹⁰)", 1024},
+		{"10⁻²", "(10 ^ (-2))", "(10⁻²)", 0.01},
+		{"2×3²", "(2 * (3 ^ 2))", "(2 × (3²))", 18},
+		{"-3²", "(-(3 ^ 2))", "(−(3²))", -9},
+		{"3²!", "((3 ^ 2)!)", "((3²)!)", 362880},
+		{"2 ^ 0.5 ^ 2", "(2 ^ (0.5 ^ 2))", "(2 ^ (0.5²))", 1.1892071150},
+		{"π", "pi", "π", math.Pi},
+		{"2π", "(2 * pi)", "(2 × π)", 2 * math.Pi},
+	}
+
+	for _, c := range cases {
+		ast, err := parser.Parse(c.source)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected error: %v", c.source, err)
+			continue
+		}
+		if ast.ToString() != c.ascii || ast.Render(parser.ASCII) != c.ascii {
+			t.Errorf("In Equation %s\n Expected %s but had %s", c.source, c.ascii, ast.ToString())
+		}
+		if text := ast.Render(parser.Unicode); text != c.unicode {
+			t.Errorf("In Equation %s\n Expected %s but had %s", c.source, c.unicode, text)
+		}
+		if value, err := ast.Eval(parser.NewEnv()); err != nil || math.Abs(value-c.value) > 1e-9 {
+			t.Errorf("In Equation %s\n Expected %g but had %g, %v", c.source, c.value, value, err)
+		}
+
+		// The Unicode form reads back as the same expression.
+		again, err := parser.Parse(ast.Render(parser.Unicode))
+		if err != nil || again.ToString() != c.ascii {
+			t.Errorf("In Equation %s\n Expected %s to read back as %s but had %v", c.source, ast.Render(parser.Unicode), c.ascii, err)
+		}
+	}
+}
+
+func TestWorkdays(t *testing.T) {
+	date := func(s string) time.Time {
+		d, _, _ := parser.ParseDate(s)
+		return d
+	}
+	cases := []struct {
+		from string
+		n    int
+		to   string
+	}{
+		{"2026-03-02", 0, "2026-03-02"},  // Monday
+		{"2026-03-06", 1, "2026-03-09"},  // Friday to Monday
+		{"2026-03-07", 1, "2026-03-09"},  // Saturday to Monday
+		{"2026-03-07", 5, "2026-03-13"},  // Saturday to Friday
+		{"2026-03-02", 10, "2026-03-16"}, // two weeks
+		{"2026-03-09", -1, "2026-03-06"}, // Monday back to Friday
+		{"2026-03-08", -1, "2026-03-06"}, // Sunday back to Friday
+		{"2026-03-16", -12, "2026-02-26"},
+	}
+
+	for _, c := range cases {
+		if result := parser.AddWorkdays(date(c.from), c.n); !result.Equal(date(c.to)) {
+			t.Errorf("%d business days from %s: expected %s but had %s", c.n, c.from, c.to, result.Format(parser.DateLayout))
+		}
+		// Counting business days inverts adding them between business days.
+		if parser.IsoWeekday(date(c.from)) > 5 {
+			continue
+		}
+		if count := parser.CountWorkdays(date(c.from), date(c.to)); count != c.n {
+			t.Errorf("Business days from %s to %s: expected %d but had %d", c.from, c.to, c.n, count)
+		}
+	}
+}
+
+func TestAngleModes(t *testing.T) {
+	cases := []struct {
+		mode           parser.AngleMode
+		eq             string
+		expextedResult float64
+	}{
+		{parser.Radians, "sin(0)", 0},
+		{parser.Radians, "cos(0)", 1},
+		{parser.Radians, "asin(1) + asin(1)", math.Pi},
+		{parser.Degrees, "sin(30)", 0.5},
+		{parser.Degrees, "sin(180)", 0},
+		{parser.Degrees, "cos(-90)", 0},
+		{parser.Degrees, "cos(360)", 1},
+		{parser.Degrees, "tan(45)", 1},
+		{parser.Degrees, "tan(90)", math.Inf(1)},
+		{parser.Degrees, "asin(1)", 90},
+		{parser.Degrees, "acos(0.5)", 60},
+		{parser.Degrees, "atan(1)", 45},
+		{parser.Gradians, "sin(100)", 1},
+		{parser.Gradians, "cos(200)", -1},
+		{parser.Gradians, "atan(1)", 50},
+		{parser.Degrees, "sin(90°)", 1},
+		{parser.Degrees, "45°", 45},
+		{parser.Gradians, "90°", 100},
+	}
+
+	for _, c := range cases {
+		env := parser.NewEnv()
+		env.Angle = c.mode
+
+		ast, err := parser.Parse(c.eq)
+		if err != nil {
+			t.Fatalf("In Equation %s\n Unexpected error: %v", c.eq, err)
+		}
+		result, err := ast.Eval(env)
+		if err != nil {
+			t.Fatalf("In Equation %s\n Unexpected evaluation error: %v", c.eq, err)
+		}
+		if result != c.expextedResult && math.Abs(result-c.expextedResult) > 1e-12 {
+			t.Errorf("In Equation %s (%s)\n Expected result is %g but the result was %g", c.eq, c.mode, c.expextedResult, result)
+		}
+	}
+}
+
+func TestConstantsTable(t *testing.T) {
+	list := parser.Constants()
+	if len(list) == 0 {
+		t.Fatal("Expected predefined constants")
+	}
+
+	for i, constant := range list {
+		if i > 0 && list[i-1].Name >= constant.Name {
+			t.Errorf("Constants not sorted: %s before %s", list[i-1].Name, constant.Name)
+		}
+
+		ast, err := parser.Parse(constant.Name)
+		if err != nil {
+			t.Fatalf("Constant %s: unexpected error: %v", constant.Name, err)
+		}
+		result, err := ast.Eval(parser.NewEnv())
+		if err != nil || result != constant.Value {
+			t.Errorf("Constant %s: expected %g but had %g (%v)", constant.Name, constant.Value, result, err)
+		}
+	}
+}
+
+func TestLenientParsing(t *testing.T) {
+	cases := map[string]string{
+		"2 3":      "2",
+		"(1+2))":   "(1 + 2)",
+		"1+2)4":    "(1 + 2)",
+		"2 * 3 4 ": "(2 * 3)",
+		"7":        "7",
+	}
+
+	for source, expected := range cases {
+		ast, err := parser.ParseWithOptions(source, parser.Options{Lenient: true})
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected error: %v", source, err)
+			continue
+		}
+		if ast.ToString() != expected {
+			t.Errorf("In Equation %s\n Expected prefix %s but had %s", source, expected, ast.ToString())
+		}
+	}
+
+	if _, err := parser.ParseWithOptions("2 +", parser.Options{Lenient: true}); err == nil {
+		t.Errorf("Expected lenient parsing to still reject an incomplete prefix")
+	}
+}
+
+func TestAssociativity(t *testing.T) {
+	cases := []struct {
+		eq             string
+		expectedTree   string
+		expextedResult float64
+	}{
+		// Left-associative chains
+		{"10 - 2 - 3", "((10 - 2) - 3)", 5},
+		{"64 / 4 / 2", "((64 / 4) / 2)", 8},
+		{"2 * 3 / 4", "((2 * 3) / 4)", 1.5},
+		{"10 - 2 + 3", "((10 - 2) + 3)", 11},
+		{"4096 r2 r3", "((4096 r 2) r 3)", 4},
+		{"256 l 4 l 2", "((256 l 4) l 2)", 2},
+
+		// Right-associative exponentiation
+		{"2 ^ 3 ^ 2", "(2 ^ (3 ^ 2))", 512},
+		{"2 ^ 2 ^ 3", "(2 ^ (2 ^ 3))", 256},
+		{"(2 ^ 3) ^ 2", "((2 ^ 3) ^ 2)", 64},
+		{"2 ^ 3 * 2", "((2 ^ 3) * 2)", 16},
+		{"2 * 3 ^ 2", "(2 * (3 ^ 2))", 18},
+
+		// Unary minus binds looser than ^ and tighter than * and /
+		{"-2 ^ 2", "(-(2 ^ 2))", -4},
+		{"-2 ^ -2", "(-(2 ^ (-2)))", -0.25},
+		{"2 ^ -2", "(2 ^ (-2))", 0.25},
+		{"(-2) ^ 2", "((-2) ^ 2)", 4},
+		{"-2 * 3", "((-2) * 3)", -6},
+		{"-2 ^ 2 * 3", "((-(2 ^ 2)) * 3)", -12},
+		{"--2 ^ 2", "(-(-(2 ^ 2)))", 4},
+
+		// Bitwise operators bind looser than arithmetic, in the order of C
+		{"1 << 2 + 1", "(1 << (2 + 1))", 8},
+		{"0xF0 | 0x0F & 0x3C", "(240 | (15 & 60))", 252},
+		{"6 xor 3 | 8", "((6 xor 3) | 8)", 13},
+		{"12 & 10 xor 5", "((12 & 10) xor 5)", 13},
+		{"256 >> 2 >> 1", "((256 >> 2) >> 1)", 32},
+		{"~5 & 7", "((~5) & 7)", 2},
+		{"~-1", "(~(-1))", 0},
+		{"-8 >> 1", "((-8) >> 1)", -4},
+
+		// Right-associative assignment
+		{"a = b = 2 ^ 3 ^ 0", "(a = (b = (2 ^ (3 ^ 0))))", 2},
+	}
+
+	for _, c := range cases {
+		ast, err := parser.Parse(c.eq)
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected error: %v", c.eq, err)
+			continue
+		}
+		if ast.ToString() != c.expectedTree {
+			t.Errorf("In Equation %s\n Expected %s but had %s", c.eq, c.expectedTree, ast.ToString())
+		}
+		result, err := ast.Eval(parser.NewEnv())
+		if err != nil {
+			t.Errorf("In Equation %s\n Unexpected evaluation error: %v", c.eq, err)
+		} else if math.Abs(result-c.expextedResult) > 1e-9 {
+			t.Errorf("In Equation %s\n Expected result is %g but the result was %g", c.eq, c.expextedResult, result)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package parser
+
+import (
+	"calculator/src/lexer"
+	"fmt"
+	"strconv"
+)
+
+// MinBase and MaxBase bound the bases results can be shown in. Digits above 9
+// are the letters A to Z.
+const (
+	MinBase = 2
+	MaxBase = 36
+)
+
+// radixNames maps the names of the common bases to the base, as written after
+// "to" in "255 to hex".
+var radixNames = map[string]int{"bin": 2, "oct": 8, "dec": 10, "hex": 16}
+
+// RadixName returns the name base is written with after "to": "hex" for 16,
+// or "base 7" for a base without a name.
+func RadixName(base int) string {
+	for name, b := range radixNames {
+		if b == base {
+			return name
+		}
+	}
+	return fmt.Sprintf("base %d", base)
+}
+
+// RadixExpr represents a value shown in another base, as in "255 to hex" or
+// "100 to base 7". It changes how the result is displayed, not its value, so
+// Eval returns the value of Value.
+type RadixExpr struct {
+	// Value is the expression being shown in Base.
+	Value Expr
+	// Base is the base from MinBase to MaxBase.
+	Base int
+	// Span is the range of the source from the start of Value to the end of
+	// the base.
+	Span lexer.Span
+}
+
+func (n RadixExpr) ToString() string {
+	return n.Render(ASCII)
+}
+func (n RadixExpr) Render(style Style) string {
+	return fmt.Sprintf("(%s to %s)", n.Value.Render(style), RadixName(n.Base))
+}
+func (n RadixExpr) Eval(env *Env) (float64, error) {
+	return n.Value.Eval(env)
+}
+func (n RadixExpr) Position() lexer.Span {
+	return n.Span
+}
+
+// radix_at reports whether the token at pos names a base, so that a
+// conversion to it is a RadixExpr rather than a unit conversion.
+func (p *parser) radix_at(pos int) bool {
+	token := p.tokens[pos]
+	if token.Kind != lexer.IDENTIFIER {
+		return false
+	}
+	_, named := radixNames[token.Value]
+	return named || (token.Value == "base" && p.tokens[pos+1].Kind == lexer.NUMBER)
+}
+
+// parse_radix_expr parses the base written after "to", as in "255 to hex" or
+// "100 to base 7".
+func parse_radix_expr(p *parser, left Expr, start int) (Expr, error) {
+	name := p.advance()
+	if base, named := radixNames[name.Value]; named {
+		return RadixExpr{Value: left, Base: base, Span: lexer.Span{Start: start, End: name.End}}, nil
+	}
+
+	literal := p.advance()
+	base, err := strconv.Atoi(literal.Value)
+	if err != nil || base < MinBase || base > MaxBase {
+		return nil, &InvalidBaseError{Literal: literal.Value, Span: literal.Span()}
+	}
+	return RadixExpr{Value: left, Base: base, Span: lexer.Span{Start: start, End: literal.End}}, nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package parser
+
+import (
+	"calculator/src/lexer"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Style selects the symbols Render writes an expression with.
+type Style int
+
+const (
+	// ASCII writes only the symbols that can be typed on any keyboard:
+	// "(sqrt(x) * (pi ^ 2))". It is the style of ToString.
+	ASCII Style = iota
+	// Unicode writes the symbols of written mathematics: "(√x × π²)".
+	Unicode
+)
+
+// unicodeOperators are the symbols the Unicode style writes operators with,
+// by the kind of their token.
+var unicodeOperators = map[lexer.TokenKind]string{
+	lexer.STAR:  "×",
+	lexer.SLASH: "÷",
+	lexer.DASH:  "−",
+}
+
+// operator returns the symbol of the operator token in style s.
+func (s Style) operator(token lexer.Token) string {
+	if symbol, ok := unicodeOperators[token.Kind]; ok && s == Unicode {
+		return symbol
+	}
+	return token.Value
+}
+
+// superscript returns exponent written in superscript, as "²" or "⁻¹", and
+// whether it is a whole number that can be written so.
+func superscript(exponent Expr) (string, bool) {
+	sign := ""
+	if unary, ok := exponent.(UnaryExpr); ok && unary.Operator.Kind == lexer.DASH {
+		sign, exponent = lexer.SuperscriptMinus, unary.Member
+	}
+	number, ok := exponent.(NumberExpr)
+	if !ok || number.Value != math.Trunc(number.Value) || number.Value < 0 || number.Value > math.MaxInt32 {
+		return "", false
+	}
+
+	superscripts := []rune(lexer.SuperscriptDigits)
+	var text strings.Builder
+	text.WriteString(sign)
+	for _, digit := range strconv.Itoa(int(number.Value)) {
+		text.WriteRune(superscripts[digit-'0'])
+	}
+	return text.String(), true
+}
+
+// renderPower writes base^exponent in style s, with the exponent in
+// superscript when it is a whole number and s is Unicode.
+func renderPower(s Style, base Expr, operator lexer.Token, exponent Expr) string {
+	if s == Unicode {
+		if text, ok := superscript(exponent); ok {
+			return fmt.Sprintf("(%s%s)", base.Render(s), text)
+		}
+	}
+	return fmt.Sprintf("(%s %s %s)", base.Render(s), s.operator(operator), exponent.Render(s))
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package units
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Rates are the exchange rates currencies are converted with. They are read
+// from a file and never fetched, so conversions are as current as the file is.
+type Rates struct {
+	// Base is the code of the currency the rates are quoted against.
+	Base string
+	// Date is when the rates were published.
+	Date time.Time
+	// Rates holds how much of each currency one Base buys, by ISO 4217 code.
+	// The rate of Base is 1.
+	Rates map[string]float64
+}
+
+// Codes returns the currency codes of r in alphabetical order.
+func (r *Rates) Codes() []string {
+	return slices.Sorted(maps.Keys(r.Rates))
+}
+
+// Stamp writes the date of r, with the time of day when it has one.
+func (r *Rates) Stamp() string {
+	if r.Date.Hour() == 0 && r.Date.Minute() == 0 {
+		return r.Date.Format(time.DateOnly)
+	}
+	return r.Date.Format("2006-01-02 15:04 MST")
+}
+
+// MarshalJSON writes r in the JSON layout ParseRates reads.
+func (r *Rates) MarshalJSON() ([]byte, error) {
+	date := r.Date.Format(time.RFC3339)
+	if r.Date.Hour() == 0 && r.Date.Minute() == 0 {
+		date = r.Date.Format(time.DateOnly)
+	}
+	return json.Marshal(struct {
+		Base  string             `json:"base"`
+		Date  string             `json:"date"`
+		Rates map[string]float64 `json:"rates"`
+	}{r.Base, date, r.Rates})
+}
+
+// rates holds the Rates set with SetRates, nil until some are.
+var rates atomic.Pointer[Rates]
+
+// SetRates makes the currencies of r units, replacing any rates set before.
+// A nil r removes every currency.
+func SetRates(r *Rates) {
+	rates.Store(r)
+}
+
+// CurrentRates returns the Rates set with SetRates, or nil.
+func CurrentRates() *Rates {
+	return rates.Load()
+}
+
+// currency returns the currency called code in the current rates, measured in
+// their base currency.
+func currency(code string) (Unit, bool) {
+	r := CurrentRates()
+	if r == nil {
+		return Unit{}, false
+	}
+	rate, exists := r.Rates[code]
+	if !exists {
+		return Unit{}, false
+	}
+	return Unit{Name: code, Factor: 1 / rate, Dimension: money}, true
+}
+
+// Exchanged reports whether measuring an amount in p in q goes through
+// exchange rates, as converting USD to EUR does and km/USD to m/USD does not.
+func Exchanged(p, q Product) bool {
+	return p.money().Scale() != q.money().Scale()
+}
+
+// money returns the currencies of p.
+func (p Product) money() Product {
+	var result Product
+	for _, factor := range p {
+		if factor.Unit.Dimension == money {
+			result = append(result, factor)
+		}
+	}
+	return result
+}
+
+// ParseRates reads exchange rates from a JSON or a CSV file.
+//
+// The JSON layout is the one of most rate services:
+//
+//	{"base": "EUR", "date": "2025-03-14", "rates": {"USD": 1.0881, "GBP": 0.8389}}
+//
+// where date may be a full RFC 3339 time, or be replaced by "timestamp" in
+// Unix seconds. The CSV layout is the one of the euro foreign exchange
+// reference rates of the European Central Bank: a header row "Date" followed
+// by the codes, then a row with the date and the rate of each against EUR.
+func ParseRates(data []byte) (*Rates, error) {
+	data = bytes.TrimSpace(data)
+	var r *Rates
+	var err error
+	if bytes.HasPrefix(data, []byte("{")) {
+		r, err = parseJSONRates(data)
+	} else {
+		r, err = parseCSVRates(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Base == "" {
+		return nil, errors.New("the rates have no base currency")
+	}
+	if r.Date.IsZero() {
+		return nil, errors.New("the rates have no date")
+	}
+	if rate, exists := r.Rates[r.Base]; exists && rate != 1 {
+		return nil, fmt.Errorf("the rate of the base currency %s must be 1", r.Base)
+	}
+	r.Rates[r.Base] = 1
+	for code, rate := range r.Rates {
+		if !isCode(code) {
+			return nil, fmt.Errorf("%q is not a currency code", code)
+		}
+		if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			return nil, fmt.Errorf("the rate of %s must be a positive number", code)
+		}
+	}
+	return r, nil
+}
+
+func parseJSONRates(data []byte) (*Rates, error) {
+	var file struct {
+		Base      string             `json:"base"`
+		Date      string             `json:"date"`
+		Timestamp int64              `json:"timestamp"`
+		Rates     map[string]float64 `json:"rates"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid rates file: %w", err)
+	}
+
+	r := &Rates{Base: strings.ToUpper(file.Base), Rates: make(map[string]float64, len(file.Rates))}
+	for code, rate := range file.Rates {
+		r.Rates[strings.ToUpper(code)] = rate
+	}
+	switch {
+	case file.Date != "":
+		date, err := parseDate(file.Date, time.DateOnly, time.RFC3339)
+		if err != nil {
+			return nil, err
+		}
+		r.Date = date
+	case file.Timestamp != 0:
+		r.Date = time.Unix(file.Timestamp, 0).UTC()
+	}
+	return r, nil
+}
+
+func parseCSVRates(data []byte) (*Rates, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid rates file: %w", err)
+	}
+	if len(rows) < 2 || len(rows[0]) < 2 || !strings.EqualFold(rows[0][0], "date") {
+		return nil, errors.New("invalid rates file: expected a Date column followed by currency codes")
+	}
+
+	header, values := rows[0], rows[1]
+	date, err := parseDate(values[0], "2 January 2006", time.DateOnly)
+	if err != nil {
+		return nil, err
+	}
+	r := &Rates{Base: "EUR", Date: date, Rates: make(map[string]float64, len(header))}
+	for i := 1; i < len(header) && i < len(values); i++ {
+		// The files of the ECB end their rows with a comma.
+		code := strings.TrimSpace(header[i])
+		if code == "" {
+			continue
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(values[i]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate %q for %s", values[i], code)
+		}
+		r.Rates[strings.ToUpper(code)] = rate
+	}
+	return r, nil
+}
+
+// parseDate reads s in the first of layouts that fits.
+func parseDate(s string, layouts ...string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, s); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q in rates file", s)
+}
+
+// isCode reports whether s is written like an ISO 4217 code, as three capital
+// letters.
+func isCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for i := range len(s) {
+		if s[i] < 'A' || 'Z' < s[i] {
+			return false
+		}
+	}
+	return true
+}
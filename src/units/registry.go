@@ -0,0 +1,327 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package units
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Dimensions of the SI base quantities and of money, combined with dim.
+var (
+	length      = Dimension{1, 0, 0, 0, 0, 0, 0, 0}
+	mass        = Dimension{0, 1, 0, 0, 0, 0, 0, 0}
+	duration    = Dimension{0, 0, 1, 0, 0, 0, 0, 0}
+	current     = Dimension{0, 0, 0, 1, 0, 0, 0, 0}
+	temperature = Dimension{0, 0, 0, 0, 1, 0, 0, 0}
+	amount      = Dimension{0, 0, 0, 0, 0, 1, 0, 0}
+	luminosity  = Dimension{0, 0, 0, 0, 0, 0, 1, 0}
+	money       = Dimension{0, 0, 0, 0, 0, 0, 0, 1}
+)
+
+// dim returns the product of the dimensions passed with their powers, as in
+// dim(length, 1, duration, -1) for speed.
+func dim(powers ...any) Dimension {
+	var result Dimension
+	for i := 0; i < len(powers); i += 2 {
+		base, power := powers[i].(Dimension), powers[i+1].(int)
+		for j := range result {
+			result[j] += base[j] * power
+		}
+	}
+	return result
+}
+
+// definition is an entry of the unit registry.
+type definition struct {
+	factor    float64
+	dimension Dimension
+	// prefixed is set for the units that take SI prefixes, as in km or mA.
+	prefixed bool
+}
+
+var (
+	area        = dim(length, 2)
+	volume      = dim(length, 3)
+	speed       = dim(length, 1, duration, -1)
+	force       = dim(mass, 1, length, 1, duration, -2)
+	energy      = dim(force, 1, length, 1)
+	power       = dim(energy, 1, duration, -1)
+	pressure    = dim(force, 1, length, -2)
+	charge      = dim(current, 1, duration, 1)
+	voltage     = dim(power, 1, current, -1)
+	resistance  = dim(voltage, 1, current, -1)
+	capacitance = dim(charge, 1, voltage, -1)
+	flux        = dim(voltage, 1, duration, 1)
+)
+
+// registry holds every unit by name. Factors are exact where the unit is
+// defined exactly in SI units, as the international yard and pound are.
+var registry = map[string]definition{
+	// SI base units. The gram is the one that takes prefixes; kg is k + g.
+	"m":   {1, length, true},
+	"g":   {1e-3, mass, true},
+	"s":   {1, duration, true},
+	"A":   {1, current, true},
+	"K":   {1, temperature, true},
+	"mol": {1, amount, true},
+	"cd":  {1, luminosity, true},
+
+	// SI derived units
+	"Hz":  {1, dim(duration, -1), true},
+	"N":   {1, force, true},
+	"Pa":  {1, pressure, true},
+	"J":   {1, energy, true},
+	"W":   {1, power, true},
+	"C":   {1, charge, true},
+	"V":   {1, voltage, true},
+	"ohm": {1, resistance, true},
+	"F":   {1, capacitance, true},
+	"Wb":  {1, flux, true},
+	"T":   {1, dim(flux, 1, length, -2), true},
+	"L":   {1e-3, volume, true},
+	"eV":  {1.602176634e-19, energy, true},
+	"Wh":  {3600, energy, true},
+	"bar": {1e5, pressure, true},
+	"cal": {4.184, energy, true},
+
+	// Time. The month and the year are averages of the Julian calendar;
+	// adding them to a date moves it on the calendar instead.
+	"min":   {60, duration, false},
+	"h":     {3600, duration, false},
+	"day":   {86400, duration, false},
+	"week":  {604800, duration, false},
+	"month": {2629800, duration, false},
+	"yr":    {31557600, duration, false},
+
+	// Length and area
+	"in":   {0.0254, length, false},
+	"ft":   {0.3048, length, false},
+	"yd":   {0.9144, length, false},
+	"mi":   {1609.344, length, false},
+	"nmi":  {1852, length, false},
+	"au":   {149597870700, length, false},
+	"ly":   {9460730472580800, length, false},
+	"ha":   {1e4, area, false},
+	"acre": {4046.8564224, area, false},
+
+	// Volume
+	"gal":  {3.785411784e-3, volume, false},
+	"qt":   {9.46352946e-4, volume, false},
+	"pt":   {4.73176473e-4, volume, false},
+	"cup":  {2.365882365e-4, volume, false},
+	"floz": {2.95735295625e-5, volume, false},
+
+	// Mass
+	"lb":    {0.45359237, mass, false},
+	"oz":    {0.028349523125, mass, false},
+	"tonne": {1000, mass, false},
+
+	// Speed
+	"mph": {0.44704, speed, false},
+	"kn":  {1852.0 / 3600, speed, false},
+
+	// Force, pressure, energy and power
+	"lbf":  {4.4482216152605, force, false},
+	"atm":  {101325, pressure, false},
+	"psi":  {6894.757293168361, pressure, false},
+	"mmHg": {133.322387415, pressure, false},
+	"BTU":  {1055.05585262, energy, false},
+	"hp":   {745.69987158227022, power, false},
+}
+
+// aliases are longer names for some units.
+var aliases = map[string]string{
+	"second": "s", "seconds": "s",
+	"minute": "min", "minutes": "min",
+	"hour": "h", "hours": "h",
+	"days":   "day",
+	"weeks":  "week",
+	"months": "month",
+	"year":   "yr", "years": "yr",
+	"meter": "m", "meters": "m", "metre": "m", "metres": "m",
+	"gram": "g", "grams": "g",
+	"liter": "L", "liters": "L", "litre": "L", "litres": "L",
+	"inch": "in", "feet": "ft", "foot": "ft", "mile": "mi", "miles": "mi",
+	"pound": "lb", "pounds": "lb",
+}
+
+// prefixes are the SI prefixes by symbol, with u standing for micro.
+var prefixes = map[string]float64{
+	"Q": 1e30, "R": 1e27, "Y": 1e24, "Z": 1e21, "E": 1e18, "P": 1e15,
+	"T": 1e12, "G": 1e9, "M": 1e6, "k": 1e3, "h": 1e2, "da": 1e1,
+	"d": 1e-1, "c": 1e-2, "m": 1e-3, "u": 1e-6, "n": 1e-9, "p": 1e-12,
+	"f": 1e-15, "a": 1e-18, "z": 1e-21, "y": 1e-24, "r": 1e-27, "q": 1e-30,
+}
+
+// Lookup returns the unit called name and whether it exists. Names are units
+// of the registry, their aliases, or an SI prefix followed by a unit that
+// takes one, such as km, ms or kWh, and the currency codes of the current
+// Rates. A registered name wins over a prefixed reading of it: "min" is the
+// minute, not a milli-inch.
+func Lookup(name string) (Unit, bool) {
+	if alias, exists := aliases[name]; exists {
+		name = alias
+	}
+	if def, exists := registry[name]; exists {
+		return Unit{Name: name, Factor: def.factor, Dimension: def.dimension}, true
+	}
+	if unit, exists := currency(name); exists {
+		return unit, true
+	}
+
+	for _, prefix := range slices.Sorted(maps.Keys(prefixes)) {
+		def, exists := registry[strings.TrimPrefix(name, prefix)]
+		if strings.HasPrefix(name, prefix) && exists && def.prefixed {
+			return Unit{Name: name, Factor: prefixes[prefix] * def.factor, Dimension: def.dimension}, true
+		}
+	}
+	return Unit{}, false
+}
+
+// IsUnit reports whether name is a unit.
+func IsUnit(name string) bool {
+	_, exists := Lookup(name)
+	return exists
+}
+
+// Names returns the names in the unit registry in alphabetical order, without
+// aliases or prefixed forms.
+func Names() []string {
+	return slices.Sorted(maps.Keys(registry))
+}
+
+// Parse reads a product of units written as in "km/h", "m/s^2", "N*m" or
+// "J/(mol*K)". Units written next to each other, as in "N m", are multiplied,
+// and "1/s" is the inverse of s. The empty string is the empty Product.
+func Parse(s string) (Product, error) {
+	r := reader{text: s}
+	if r.done() {
+		return nil, nil
+	}
+	product, err := r.product()
+	if err != nil {
+		return nil, err
+	}
+	if !r.done() {
+		return nil, fmt.Errorf("unexpected %q in unit %q", r.text[r.pos:], s)
+	}
+	return product, nil
+}
+
+// reader is a recursive descent parser for the unit syntax of Parse.
+type reader struct {
+	text string
+	pos  int
+}
+
+func (r *reader) skipSpace() {
+	for r.pos < len(r.text) && r.text[r.pos] == ' ' {
+		r.pos++
+	}
+}
+
+func (r *reader) done() bool {
+	r.skipSpace()
+	return r.pos >= len(r.text)
+}
+
+func (r *reader) peek() byte {
+	r.skipSpace()
+	if r.pos >= len(r.text) {
+		return 0
+	}
+	return r.text[r.pos]
+}
+
+// product reads terms separated by "*", "/" or spaces. A "/" divides by the
+// term right after it only, so "W/m^2*K" is W*K/m^2.
+func (r *reader) product() (Product, error) {
+	result, err := r.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch c := r.peek(); {
+		case c == '*' || c == '/':
+			r.pos++
+			term, err := r.term()
+			if err != nil {
+				return nil, err
+			}
+			if c == '/' {
+				term = term.Inverse()
+			}
+			result = result.Mul(term)
+		case c == '(' || isNameStart(c):
+			term, err := r.term()
+			if err != nil {
+				return nil, err
+			}
+			result = result.Mul(term)
+		default:
+			return result, nil
+		}
+	}
+}
+
+// term reads a unit, a "1" or a parenthesised product, raised to an optional
+// integer power.
+func (r *reader) term() (Product, error) {
+	var base Product
+	switch c := r.peek(); {
+	case c == '(':
+		r.pos++
+		inner, err := r.product()
+		if err != nil {
+			return nil, err
+		}
+		if r.peek() != ')' {
+			return nil, fmt.Errorf("missing ')' in unit %q", r.text)
+		}
+		r.pos++
+		base = inner
+	case c == '1':
+		r.pos++
+	case isNameStart(c):
+		start := r.pos
+		for r.pos < len(r.text) && (isNameStart(r.text[r.pos]) || unicode.IsDigit(rune(r.text[r.pos]))) {
+			r.pos++
+		}
+		name := r.text[start:r.pos]
+		unit, exists := Lookup(name)
+		if !exists {
+			return nil, &UnknownUnitError{Name: name}
+		}
+		base = Of(unit)
+	default:
+		return nil, fmt.Errorf("expected a unit in %q", r.text)
+	}
+
+	if r.peek() != '^' {
+		return base, nil
+	}
+	r.pos++
+	r.skipSpace()
+	start := r.pos
+	if r.pos < len(r.text) && r.text[r.pos] == '-' {
+		r.pos++
+	}
+	for r.pos < len(r.text) && unicode.IsDigit(rune(r.text[r.pos])) {
+		r.pos++
+	}
+	n, err := strconv.Atoi(r.text[start:r.pos])
+	if err != nil {
+		return nil, fmt.Errorf("the power of a unit must be an integer in %q", r.text)
+	}
+	result, _ := base.Pow(float64(n))
+	return result, nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+
+// Package units holds the registry of physical units the calculator knows,
+// the exchange rates currencies are converted with, and the products of units,
+// such as km/h or EUR/L, that quantities are measured in.
+package units
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Dimension counts the powers of the seven SI base quantities: length, mass,
+// time, electric current, temperature, amount of substance and luminous
+// intensity, followed by money. Speed, for example, is length^1 time^-1.
+type Dimension [8]int
+
+// Unit is a named unit, such as km, with its size in SI base units.
+type Unit struct {
+	// Name is the symbol the unit is written with.
+	Name string
+	// Factor is the size of the unit in the SI base units of its dimension:
+	// 1000 for km, 3600 for h. Currencies are measured in the base currency
+	// of the current Rates.
+	Factor float64
+	// Dimension is what the unit measures.
+	Dimension Dimension
+}
+
+// Factor is a unit raised to a power, such as the s^-2 of m/s^2.
+type Factor struct {
+	Unit  Unit
+	Power int
+}
+
+// Product is a product of units raised to integer powers, such as km/h, in
+// the order they were written. The empty Product is the unit of plain numbers.
+type Product []Factor
+
+// Of returns the Product made of unit alone.
+func Of(unit Unit) Product {
+	return Product{{Unit: unit, Power: 1}}
+}
+
+// Mul returns p*q. Powers of the same unit are added, and units whose power
+// drops to 0 are removed, so m/s * s is m.
+func (p Product) Mul(q Product) Product {
+	result := make(Product, len(p), len(p)+len(q))
+	copy(result, p)
+	for _, factor := range q {
+		result = result.with(factor)
+	}
+	return result
+}
+
+// with multiplies p by a single factor.
+func (p Product) with(factor Factor) Product {
+	for i := range p {
+		if p[i].Unit.Name == factor.Unit.Name {
+			p[i].Power += factor.Power
+			if p[i].Power == 0 {
+				return append(p[:i], p[i+1:]...)
+			}
+			return p
+		}
+	}
+	return append(p, factor)
+}
+
+// Inverse returns 1/p.
+func (p Product) Inverse() Product {
+	result := make(Product, len(p))
+	for i, factor := range p {
+		result[i] = Factor{Unit: factor.Unit, Power: -factor.Power}
+	}
+	return result
+}
+
+// Cancel merges units of the same dimension that divide each other into the
+// first of them, as the min and h of km*min/h. It returns the merged Product
+// and the factor amounts in p must be multiplied by to be measured in it.
+func (p Product) Cancel() (Product, float64) {
+	result := slices.Clone(p)
+	scale := 1.0
+	for i := 0; i < len(result); i++ {
+		for j := i + 1; j < len(result); j++ {
+			a, b := result[i], result[j]
+			if a.Unit.Dimension != b.Unit.Dimension || (a.Power > 0) == (b.Power > 0) {
+				continue
+			}
+			scale *= math.Pow(b.Unit.Factor/a.Unit.Factor, float64(b.Power))
+			result[i].Power += b.Power
+			result = slices.Delete(result, j, j+1)
+			j--
+			if result[i].Power == 0 {
+				result = slices.Delete(result, i, i+1)
+				i--
+				break
+			}
+		}
+	}
+	return result, scale
+}
+
+// Pow returns p raised to the power n, and false when a power of a unit in
+// the result would not be an integer, as for sqrt(m).
+func (p Product) Pow(n float64) (Product, bool) {
+	result := make(Product, 0, len(p))
+	for _, factor := range p {
+		power := float64(factor.Power) * n
+		if power != math.Trunc(power) {
+			return nil, false
+		}
+		if power != 0 {
+			result = append(result, Factor{Unit: factor.Unit, Power: int(power)})
+		}
+	}
+	return result, true
+}
+
+// Scale returns the size of p in SI base units: 1000/3600 for km/h.
+func (p Product) Scale() float64 {
+	scale := 1.0
+	for _, factor := range p {
+		scale *= math.Pow(factor.Unit.Factor, float64(factor.Power))
+	}
+	return scale
+}
+
+// Dimension returns what p measures.
+func (p Product) Dimension() Dimension {
+	var dimension Dimension
+	for _, factor := range p {
+		for i, power := range factor.Unit.Dimension {
+			dimension[i] += power * factor.Power
+		}
+	}
+	return dimension
+}
+
+// IsDimensionless reports whether p measures a plain number, as km/m does.
+func (p Product) IsDimensionless() bool {
+	return p.Dimension() == Dimension{}
+}
+
+// String writes p the way it is typed, such as "kg*m/s^2" or "J/(mol*K)".
+func (p Product) String() string {
+	var numerator, denominator []string
+	for _, factor := range p {
+		if factor.Power > 0 {
+			numerator = append(numerator, raised(factor.Unit.Name, factor.Power))
+		} else {
+			denominator = append(denominator, raised(factor.Unit.Name, -factor.Power))
+		}
+	}
+
+	text := strings.Join(numerator, "*")
+	switch {
+	case len(denominator) == 0:
+		return text
+	case len(numerator) == 0:
+		text = "1"
+	}
+	if len(denominator) == 1 {
+		return text + "/" + denominator[0]
+	}
+	return text + "/(" + strings.Join(denominator, "*") + ")"
+}
+
+// raised writes a unit name raised to the power n.
+func raised(name string, n int) string {
+	if n == 1 {
+		return name
+	}
+	return fmt.Sprintf("%s^%d", name, n)
+}
+
+// Convertible reports whether values in p can be expressed in q, that is
+// whether both measure the same dimension.
+func Convertible(p, q Product) bool {
+	return p.Dimension() == q.Dimension()
+}
+
+// IncompatibleError is returned when quantities of different dimensions are
+// added, compared or converted, as in "1 m + 1 s".
+type IncompatibleError struct {
+	// From and To are the units of the two quantities.
+	From, To Product
+}
+
+func (e *IncompatibleError) Error() string {
+	switch {
+	case len(e.From) == 0:
+		return fmt.Sprintf("a number without units is incompatible with %s", e.To)
+	case len(e.To) == 0:
+		return fmt.Sprintf("%s is incompatible with a number without units", e.From)
+	}
+	return fmt.Sprintf("incompatible units %s and %s", e.From, e.To)
+}
+
+// UnknownUnitError is returned by Parse for a name that is not a unit.
+type UnknownUnitError struct {
+	// Name is the unrecognized name.
+	Name string
+}
+
+func (e *UnknownUnitError) Error() string {
+	return fmt.Sprintf("unknown unit %q", e.Name)
+}
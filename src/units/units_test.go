@@ -0,0 +1,229 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+package units_test
+
+import (
+	"calculator/src/units"
+	"encoding/json"
+	"errors"
+	"maps"
+	"math"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	cases := []struct {
+		name   string
+		factor float64
+	}{
+		{"m", 1},
+		{"km", 1000},
+		{"kg", 1},
+		{"mg", 1e-6},
+		{"ms", 1e-3},
+		{"us", 1e-6},
+		{"kWh", 3.6e6},
+		{"dam", 10},
+		{"min", 60},
+		{"h", 3600},
+		{"hours", 3600},
+		{"mi", 1609.344},
+		{"ft", 0.3048},
+	}
+
+	for _, c := range cases {
+		unit, exists := units.Lookup(c.name)
+		if !exists {
+			t.Errorf("Expected %s to be a unit", c.name)
+			continue
+		}
+		if math.Abs(unit.Factor-c.factor) > 1e-12*c.factor {
+			t.Errorf("Expected %s to be %g SI units but had %g", c.name, c.factor, unit.Factor)
+		}
+	}
+
+	for _, name := range []string{"x", "kmin", "kft", "pi", "e", "to"} {
+		if units.IsUnit(name) {
+			t.Errorf("Expected %s not to be a unit", name)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		unit, expected string
+		scale          float64
+	}{
+		{"km/h", "km/h", 1000.0 / 3600},
+		{"m/s^2", "m/s^2", 1},
+		{"N m", "N*m", 1},
+		{"kg*m/s^2", "kg*m/s^2", 1},
+		{"J/(mol*K)", "J/(mol*K)", 1},
+		{"1/mol", "1/mol", 1},
+		{"m*m", "m^2", 1},
+		{"m/s*s", "m", 1},
+		{"W/m^2*K", "W*K/m^2", 1},
+		{"s^-1", "1/s", 1},
+	}
+
+	for _, c := range cases {
+		product, err := units.Parse(c.unit)
+		if err != nil {
+			t.Errorf("In unit %s\n Unexpected error: %v", c.unit, err)
+			continue
+		}
+		if product.String() != c.expected {
+			t.Errorf("In unit %s\n Expected %s but had %s", c.unit, c.expected, product.String())
+		}
+		if math.Abs(product.Scale()-c.scale) > 1e-12 {
+			t.Errorf("In unit %s\n Expected a scale of %g but had %g", c.unit, c.scale, product.Scale())
+		}
+	}
+
+	for _, unit := range []string{"parsec", "m^x", "(m", "m)", "m^1.5"} {
+		if _, err := units.Parse(unit); err == nil {
+			t.Errorf("In unit %s\n Expected an error", unit)
+		}
+	}
+
+	var unknown *units.UnknownUnitError
+	if _, err := units.Parse("m/parsec"); !errors.As(err, &unknown) || unknown.Name != "parsec" {
+		t.Errorf("Expected an unknown unit error for parsec but had %v", err)
+	}
+}
+
+func TestDimensions(t *testing.T) {
+	speed, _ := units.Parse("m/s")
+	for _, unit := range []string{"km/h", "mph", "kn", "ft/min"} {
+		product, _ := units.Parse(unit)
+		if !units.Convertible(product, speed) {
+			t.Errorf("Expected %s to be a speed", unit)
+		}
+	}
+
+	same := [][2]string{{"N", "kg*m/s^2"}, {"J", "N*m"}, {"W", "J/s"}, {"Pa", "N/m^2"}, {"L", "dm^3"}, {"Hz", "1/s"}}
+	for _, pair := range same {
+		a, _ := units.Parse(pair[0])
+		b, _ := units.Parse(pair[1])
+		if !units.Convertible(a, b) || math.Abs(a.Scale()-b.Scale()) > 1e-12*a.Scale() {
+			t.Errorf("Expected %s to equal %s", pair[0], pair[1])
+		}
+	}
+
+	meter, _ := units.Parse("m")
+	second, _ := units.Parse("s")
+	if units.Convertible(meter, second) {
+		t.Errorf("Expected m and s to be incompatible")
+	}
+	if !meter.Mul(meter.Inverse()).IsDimensionless() {
+		t.Errorf("Expected m/m to be dimensionless")
+	}
+	if _, ok := meter.Pow(0.5); ok {
+		t.Errorf("Expected no square root of m")
+	}
+}
+
+func TestRegistryNames(t *testing.T) {
+	for _, name := range units.Names() {
+		if _, err := units.Parse(name); err != nil {
+			t.Errorf("Unit %s: unexpected error: %v", name, err)
+		}
+	}
+}
+
+func TestParseRates(t *testing.T) {
+	cases := []struct {
+		name, file, base, stamp string
+		rates                   map[string]float64
+	}{
+		{
+			"json",
+			`{"base": "EUR", "date": "2025-03-14", "rates": {"USD": 1.0881, "gbp": 0.8389}}`,
+			"EUR", "2025-03-14",
+			map[string]float64{"EUR": 1, "USD": 1.0881, "GBP": 0.8389},
+		},
+		{
+			"timestamp",
+			`{"base": "USD", "timestamp": 1741960800, "rates": {"USD": 1, "JPY": 148.62}}`,
+			"USD", "2025-03-14 14:00 UTC",
+			map[string]float64{"USD": 1, "JPY": 148.62},
+		},
+		{
+			"ecb csv",
+			"Date, USD, JPY, GBP, \n14 March 2025, 1.0881, 161.69, 0.8389, \n",
+			"EUR", "2025-03-14",
+			map[string]float64{"EUR": 1, "USD": 1.0881, "JPY": 161.69, "GBP": 0.8389},
+		},
+	}
+
+	for _, c := range cases {
+		r, err := units.ParseRates([]byte(c.file))
+		if err != nil {
+			t.Errorf("Rates %s: unexpected error: %v", c.name, err)
+			continue
+		}
+		if r.Base != c.base || r.Stamp() != c.stamp {
+			t.Errorf("Rates %s: expected %s rates of %s but had %s rates of %s", c.name, c.base, c.stamp, r.Base, r.Stamp())
+		}
+		if !maps.Equal(r.Rates, c.rates) {
+			t.Errorf("Rates %s: expected %v but had %v", c.name, c.rates, r.Rates)
+		}
+
+		data, err := json.Marshal(r)
+		if err != nil {
+			t.Errorf("Rates %s: unexpected error writing them: %v", c.name, err)
+			continue
+		}
+		if saved, err := units.ParseRates(data); err != nil || !saved.Date.Equal(r.Date) || !maps.Equal(saved.Rates, r.Rates) {
+			t.Errorf("Rates %s: expected to read back the rates written as %s", c.name, data)
+		}
+	}
+
+	invalid := []string{
+		`{"base": "EUR", "rates": {"USD": 1.0881}}`,
+		`{"date": "2025-03-14", "rates": {"USD": 1.0881}}`,
+		`{"base": "EUR", "date": "2025-03-14", "rates": {"USD": -1}}`,
+		`{"base": "EUR", "date": "2025-03-14", "rates": {"EUR": 2}}`,
+		`{"base": "EUR", "date": "2025-03-14", "rates": {"dollar": 1.0881}}`,
+		`{"base": "EUR", "date": "14/03/2025", "rates": {}}`,
+		"USD,1.0881\n",
+		"Date, USD\n14 March 2025, N/A\n",
+	}
+	for _, file := range invalid {
+		if _, err := units.ParseRates([]byte(file)); err == nil {
+			t.Errorf("Expected an error for rates %q", file)
+		}
+	}
+}
+
+func TestCurrencies(t *testing.T) {
+	r, err := units.ParseRates([]byte(`{"base": "EUR", "date": "2025-03-14", "rates": {"USD": 1.25, "GBP": 0.8}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	units.SetRates(r)
+	t.Cleanup(func() { units.SetRates(nil) })
+
+	usd, _ := units.Parse("USD")
+	gbp, _ := units.Parse("GBP")
+	if !units.Convertible(usd, gbp) || usd.Scale()/gbp.Scale() != 0.64 {
+		t.Errorf("Expected 1 USD to be 0.64 GBP but had %g", usd.Scale()/gbp.Scale())
+	}
+	perLiter, _ := units.Parse("USD/L")
+	perGallon, _ := units.Parse("USD/gal")
+	if units.Exchanged(perLiter, perGallon) {
+		t.Errorf("Expected USD/L to convert to USD/gal without exchange rates")
+	}
+	if !units.Exchanged(usd, gbp) {
+		t.Errorf("Expected USD to convert to GBP with exchange rates")
+	}
+	meter, _ := units.Parse("m")
+	if units.Convertible(usd, meter) {
+		t.Errorf("Expected USD and m to be incompatible")
+	}
+
+	units.SetRates(nil)
+	if units.IsUnit("USD") {
+		t.Errorf("Expected USD not to be a unit without rates")
+	}
+}
@@ -5,6 +5,9 @@ package views
 
 import (
 	"calculator/src/controller"
+	"calculator/src/evaluator"
+	"calculator/src/parser"
+	"fmt"
 
 	"fyne.io/fyne/v2"
 	// "fyne.io/fyne/v2/app"
@@ -15,61 +18,251 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
-func CreateApp() fyne.CanvasObject {
+// Preference keys holding the display modes chosen in the top row.
+const (
+	angleModePref      = "angle_mode"
+	fractionFormatPref = "fraction_format"
+	complexFormatPref  = "complex_format"
+	basePref           = "base"
+)
+
+// CreateApp builds the calculator interface and binds the keyboard of w to it,
+// so names and assignments can be typed as well as clicked.
+func CreateApp(w fyne.Window) fyne.CanvasObject {
 
 	display := widget.NewMultiLineEntry()
 	display.SetText("")
 	display.Disable()
 
 	ctr := controller.New(display)
+	prefs := fyne.CurrentApp().Preferences()
+	LoadOptions(ctr, prefs)
+	LoadRates(fyne.CurrentApp())
+
+	// The Programmer backend has its own keypad, swapped in when it is
+	// selected in the settings. The standard keypad is created again after
+	// the settings are saved, as its decimal mark and argument separator
+	// follow the locale.
+	programmer := CreateProgrammerKeypad(ctr)
+	keypad := container.NewStack()
+	showKeypad := func() {
+		if ctr.Options().Backend == evaluator.Programmer {
+			keypad.Objects = []fyne.CanvasObject{programmer}
+		} else {
+			keypad.Objects = []fyne.CanvasObject{CreateKeypad(w, ctr)}
+		}
+		keypad.Refresh()
+	}
+	showKeypad()
+
 	app := container.New(
 		layout.NewVBoxLayout(),
 		container.NewStack(display),
 		container.NewHBox(
 			widget.NewButtonWithIcon("", theme.ContentUndoIcon(), func() { ctr.GoBack() }),
 			widget.NewButtonWithIcon("", theme.ContentRedoIcon(), func() { ctr.GoFront() }),
+			layout.NewSpacer(),
+			CreateFractionFormatBtn(ctr, prefs),
+			CreateComplexFormatBtn(ctr, prefs),
+			CreateBaseBtn(ctr, prefs),
+			CreateAngleModeBtn(ctr, prefs),
+			widget.NewButtonWithIcon("", theme.SettingsIcon(), func() { ShowSettings(w, ctr, prefs, showKeypad) }),
 		),
-		container.NewGridWithRows(5,
-			container.NewHBox(
-				CreateDefaultBtn("<-", func() { ctr.MoveCursorLeft() }),
-				CreateDefaultBtn("->", func() { ctr.MoveCursorRigth() }),
-				CreateDefaultBtn("D", func() { ctr.Delete() }),
-				CreateDefaultBtn("C", func() { ctr.Clear() }),
-				CreateDefaultBtn("=", func() { ctr.Calculate() }),
-			),
-			container.NewHBox(
-				CreateDefaultBtn("1", func() { ctr.Insert("1") }),
-				CreateDefaultBtn("2", func() { ctr.Insert("2") }),
-				CreateDefaultBtn("3", func() { ctr.Insert("3") }),
-				CreateDefaultBtn("+", func() { ctr.Insert("+") }),
-				CreateDefaultBtn("(", func() { ctr.Insert("(") }),
-			),
-			container.NewHBox(
-				CreateDefaultBtn("4", func() { ctr.Insert("4") }),
-				CreateDefaultBtn("5", func() { ctr.Insert("5") }),
-				CreateDefaultBtn("6", func() { ctr.Insert("6") }),
-				CreateDefaultBtn("-", func() { ctr.Insert("-") }),
-				CreateDefaultBtn(")", func() { ctr.Insert(")") }),
-			),
-			container.NewHBox(
-				CreateDefaultBtn("7", func() { ctr.Insert("7") }),
-				CreateDefaultBtn("8", func() { ctr.Insert("8") }),
-				CreateDefaultBtn("9", func() { ctr.Insert("9") }),
-				CreateDefaultBtn("*", func() { ctr.Insert("*") }),
-				CreateDefaultBtn("^", func() { ctr.Insert("^") }),
-			),
-			container.NewHBox(
-				CreateDefaultBtn("log", func() { ctr.Insert("l") }),
-				CreateDefaultBtn("0", func() { ctr.Insert("0") }),
-				CreateDefaultBtn(".", func() { ctr.Insert(".") }),
-				CreateDefaultBtn("/", func() { ctr.Insert("/") }),
-				CreateDefaultBtn("rt", func() { ctr.Insert("r") }),
-			),
-		),
+		keypad,
 	)
+	BindKeyboard(w.Canvas(), ctr)
 	ctr.WriteInDisplay()
 	return app
 }
+
+// CreateKeypad creates the keypad of the decimal backends, with the decimal
+// mark and argument separator of the current locale.
+func CreateKeypad(w fyne.Window, ctr *controller.CalculatorController) fyne.CanvasObject {
+	locale := ctr.Formatter().Locale
+	decimal, separator := string(locale.Decimal()), string(locale.Separator())
+	return container.NewGridWithRows(6,
+		container.NewHBox(
+			CreateDefaultBtn("<-", func() { ctr.MoveCursorLeft() }),
+			CreateDefaultBtn("->", func() { ctr.MoveCursorRigth() }),
+			CreateDefaultBtn("D", func() { ctr.Delete() }),
+			CreateDefaultBtn("C", func() { ctr.Clear() }),
+			CreateDefaultBtn("=", func() { ctr.Calculate() }),
+		),
+		container.NewHBox(
+			CreateDefaultBtn("1", func() { ctr.Insert("1") }),
+			CreateDefaultBtn("2", func() { ctr.Insert("2") }),
+			CreateDefaultBtn("3", func() { ctr.Insert("3") }),
+			CreateDefaultBtn("+", func() { ctr.Insert("+") }),
+			CreateDefaultBtn("(", func() { ctr.Insert("(") }),
+		),
+		container.NewHBox(
+			CreateDefaultBtn("4", func() { ctr.Insert("4") }),
+			CreateDefaultBtn("5", func() { ctr.Insert("5") }),
+			CreateDefaultBtn("6", func() { ctr.Insert("6") }),
+			CreateDefaultBtn("-", func() { ctr.Insert("-") }),
+			CreateDefaultBtn(")", func() { ctr.Insert(")") }),
+		),
+		container.NewHBox(
+			CreateDefaultBtn("7", func() { ctr.Insert("7") }),
+			CreateDefaultBtn("8", func() { ctr.Insert("8") }),
+			CreateDefaultBtn("9", func() { ctr.Insert("9") }),
+			CreateDefaultBtn("*", func() { ctr.Insert("*") }),
+			CreateDefaultBtn("^", func() { ctr.Insert("^") }),
+		),
+		container.NewHBox(
+			CreateDefaultBtn("log", func() { ctr.Insert("l") }),
+			CreateDefaultBtn("0", func() { ctr.Insert("0") }),
+			CreateDefaultBtn(decimal, func() { ctr.Insert(decimal) }),
+			CreateDefaultBtn("/", func() { ctr.Insert("/") }),
+			CreateDefaultBtn("rt", func() { ctr.Insert("r") }),
+		),
+		container.NewHBox(
+			CreateDefaultBtn("π", func() { ctr.Insert("pi") }),
+			CreateDefaultBtn("e", func() { ctr.Insert("e") }),
+			CreateDefaultBtn(separator, func() { ctr.Insert(separator) }),
+			CreateDefaultBtn("%", func() { ctr.Insert("%") }),
+			CreateDefaultBtn("const", func() { ShowConstants(w, ctr) }),
+		),
+	)
+}
+
+// BindKeyboard forwards typed characters to the equation and maps the
+// editing keys to the matching keypad actions.
+func BindKeyboard(canvas fyne.Canvas, ctr *controller.CalculatorController) {
+	canvas.SetOnTypedRune(func(r rune) { ctr.Insert(string(r)) })
+	canvas.SetOnTypedKey(func(key *fyne.KeyEvent) {
+		switch key.Name {
+		case fyne.KeyReturn, fyne.KeyEnter:
+			ctr.Calculate()
+		case fyne.KeyBackspace:
+			ctr.Delete()
+		case fyne.KeyEscape:
+			ctr.Clear()
+		case fyne.KeyLeft:
+			ctr.MoveCursorLeft()
+		case fyne.KeyRight:
+			ctr.MoveCursorRigth()
+		}
+	})
+}
+
+// CreateAngleModeBtn creates the DEG/RAD/GRAD indicator shown next to the display.
+// It restores the mode saved in prefs and cycles through the modes when tapped,
+// saving the new one so it survives restarts.
+func CreateAngleModeBtn(ctr *controller.CalculatorController, prefs fyne.Preferences) fyne.CanvasObject {
+	mode, err := parser.ParseAngleMode(prefs.StringWithFallback(angleModePref, parser.Radians.String()))
+	if err != nil {
+		fyne.LogError("Failed to load angle mode", err)
+	}
+	ctr.SetAngleMode(mode)
+
+	btn := widget.NewButton(mode.String(), nil)
+	btn.OnTapped = func() {
+		next := ctr.AngleMode().Next()
+		ctr.SetAngleMode(next)
+		prefs.SetString(angleModePref, next.String())
+		btn.SetText(next.String())
+	}
+	return btn
+}
+
+// CreateFractionFormatBtn creates the button choosing how exact fractions from the
+// Rational backend are displayed. Like the angle mode it cycles when tapped, is
+// saved in prefs, and immediately redisplays the result currently shown.
+func CreateFractionFormatBtn(ctr *controller.CalculatorController, prefs fyne.Preferences) fyne.CanvasObject {
+	format, err := evaluator.ParseFractionFormat(prefs.StringWithFallback(fractionFormatPref, evaluator.Fraction.String()))
+	if err != nil {
+		fyne.LogError("Failed to load fraction format", err)
+	}
+	ctr.SetFractionFormat(format)
+
+	btn := widget.NewButton(format.String(), nil)
+	btn.OnTapped = func() {
+		next := ctr.FractionFormat().Next()
+		ctr.SetFractionFormat(next)
+		prefs.SetString(fractionFormatPref, next.String())
+		btn.SetText(next.String())
+	}
+	return btn
+}
+
+// CreateComplexFormatBtn creates the button switching results of the Complex
+// backend between rectangular and polar form. It behaves like the fraction
+// format button.
+func CreateComplexFormatBtn(ctr *controller.CalculatorController, prefs fyne.Preferences) fyne.CanvasObject {
+	format, err := evaluator.ParseComplexFormat(prefs.StringWithFallback(complexFormatPref, evaluator.Rectangular.String()))
+	if err != nil {
+		fyne.LogError("Failed to load complex format", err)
+	}
+	ctr.SetComplexFormat(format)
+
+	btn := widget.NewButton(format.String(), nil)
+	btn.OnTapped = func() {
+		next := ctr.ComplexFormat().Next()
+		ctr.SetComplexFormat(next)
+		prefs.SetString(complexFormatPref, next.String())
+		btn.SetText(next.String())
+	}
+	return btn
+}
+
+// CreateBaseBtn creates the button choosing the base results are displayed in.
+// Tapping it opens a menu with the common bases and a submenu with all of them
+// from 2 to 36. The base is saved in prefs, and the result currently shown is
+// redisplayed in it.
+func CreateBaseBtn(ctr *controller.CalculatorController, prefs fyne.Preferences) fyne.CanvasObject {
+	base := prefs.IntWithFallback(basePref, 10)
+	if base < parser.MinBase || base > parser.MaxBase {
+		base = 10
+	}
+	ctr.SetBase(base)
+
+	btn := widget.NewButton(baseLabel(base), nil)
+	choose := func(base int) func() {
+		return func() {
+			ctr.SetBase(base)
+			prefs.SetInt(basePref, base)
+			btn.SetText(baseLabel(base))
+		}
+	}
+
+	others := make([]*fyne.MenuItem, 0, parser.MaxBase-parser.MinBase+1)
+	for base := parser.MinBase; base <= parser.MaxBase; base++ {
+		others = append(others, fyne.NewMenuItem(fmt.Sprintf("Base %d", base), choose(base)))
+	}
+	other := fyne.NewMenuItem("Other", nil)
+	other.ChildMenu = fyne.NewMenu("", others...)
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("Decimal", choose(10)),
+		fyne.NewMenuItem("Hexadecimal", choose(16)),
+		fyne.NewMenuItem("Octal", choose(8)),
+		fyne.NewMenuItem("Binary", choose(2)),
+		other,
+	)
+
+	btn.OnTapped = func() {
+		driver := fyne.CurrentApp().Driver()
+		position := driver.AbsolutePositionForObject(btn).Add(fyne.NewPos(0, btn.Size().Height))
+		widget.ShowPopUpMenuAtPosition(menu, driver.CanvasForObject(btn), position)
+	}
+	return btn
+}
+
+// baseLabel returns the short indicator shown for base.
+func baseLabel(base int) string {
+	switch base {
+	case 10:
+		return "DEC"
+	case 16:
+		return "HEX"
+	case 8:
+		return "OCT"
+	case 2:
+		return "BIN"
+	}
+	return fmt.Sprintf("B%d", base)
+}
+
 func CreateDefaultBtn(label string, onClick func()) fyne.CanvasObject {
 	return CreateBtn(label, onClick, 50, 50)
 }
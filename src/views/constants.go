@@ -0,0 +1,44 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+
+package views
+
+import (
+	"calculator/src/controller"
+	"calculator/src/parser"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ShowConstants opens a dialog listing every predefined constant with its value
+// and unit. Selecting one inserts its name in the equation and closes the dialog.
+func ShowConstants(w fyne.Window, ctr *controller.CalculatorController) {
+	constants := parser.Constants()
+
+	list := widget.NewList(
+		func() int { return len(constants) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			item.(*widget.Label).SetText(FormatConstant(constants[id]))
+		},
+	)
+
+	d := dialog.NewCustom("Constants", "Close", list, w)
+	list.OnSelected = func(id widget.ListItemID) {
+		ctr.Insert(constants[id].Name)
+		d.Hide()
+	}
+	d.Resize(fyne.NewSize(320, 450))
+	d.Show()
+}
+
+// FormatConstant returns the line shown for a constant in the constants list.
+func FormatConstant(constant parser.Constant) string {
+	if constant.Unit == "" {
+		return fmt.Sprintf("%s = %g\n%s", constant.Name, constant.Value, constant.Description)
+	}
+	return fmt.Sprintf("%s = %g %s\n%s", constant.Name, constant.Value, constant.Unit, constant.Description)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+
+package views
+
+import (
+	"calculator/src/controller"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+)
+
+// CreateProgrammerKeypad creates the keypad shown with the Programmer backend.
+// It has the hexadecimal digits, the prefixes of hexadecimal, binary and octal
+// literals and the bitwise operators in place of the functions and constants.
+// The hexadecimal digits are lowercase so they are not mistaken for the
+// delete and clear keys.
+func CreateProgrammerKeypad(ctr *controller.CalculatorController) fyne.CanvasObject {
+	return container.NewGridWithRows(7,
+		container.NewHBox(
+			CreateDefaultBtn("<-", func() { ctr.MoveCursorLeft() }),
+			CreateDefaultBtn("->", func() { ctr.MoveCursorRigth() }),
+			CreateDefaultBtn("D", func() { ctr.Delete() }),
+			CreateDefaultBtn("C", func() { ctr.Clear() }),
+			CreateDefaultBtn("(", func() { ctr.Insert("(") }),
+			CreateDefaultBtn(")", func() { ctr.Insert(")") }),
+		),
+		container.NewHBox(
+			CreateDefaultBtn("a", func() { ctr.Insert("a") }),
+			CreateDefaultBtn("b", func() { ctr.Insert("b") }),
+			CreateDefaultBtn("c", func() { ctr.Insert("c") }),
+			CreateDefaultBtn("d", func() { ctr.Insert("d") }),
+			CreateDefaultBtn("e", func() { ctr.Insert("e") }),
+			CreateDefaultBtn("f", func() { ctr.Insert("f") }),
+		),
+		container.NewHBox(
+			CreateDefaultBtn("7", func() { ctr.Insert("7") }),
+			CreateDefaultBtn("8", func() { ctr.Insert("8") }),
+			CreateDefaultBtn("9", func() { ctr.Insert("9") }),
+			CreateDefaultBtn("&", func() { ctr.Insert("&") }),
+			CreateDefaultBtn("|", func() { ctr.Insert("|") }),
+			// Spaced so that the keyword does not run into a name or a digit.
+			CreateDefaultBtn("xor", func() { ctr.Insert(" xor ") }),
+		),
+		container.NewHBox(
+			CreateDefaultBtn("4", func() { ctr.Insert("4") }),
+			CreateDefaultBtn("5", func() { ctr.Insert("5") }),
+			CreateDefaultBtn("6", func() { ctr.Insert("6") }),
+			CreateDefaultBtn("<<", func() { ctr.Insert("<<") }),
+			CreateDefaultBtn(">>", func() { ctr.Insert(">>") }),
+			CreateDefaultBtn("~", func() { ctr.Insert("~") }),
+		),
+		container.NewHBox(
+			CreateDefaultBtn("1", func() { ctr.Insert("1") }),
+			CreateDefaultBtn("2", func() { ctr.Insert("2") }),
+			CreateDefaultBtn("3", func() { ctr.Insert("3") }),
+			CreateDefaultBtn("*", func() { ctr.Insert("*") }),
+			CreateDefaultBtn("/", func() { ctr.Insert("/") }),
+			CreateDefaultBtn("%", func() { ctr.Insert("%") }),
+		),
+		container.NewHBox(
+			CreateDefaultBtn("0", func() { ctr.Insert("0") }),
+			CreateDefaultBtn("0x", func() { ctr.Insert("0x") }),
+			CreateDefaultBtn("0b", func() { ctr.Insert("0b") }),
+			CreateDefaultBtn("0o", func() { ctr.Insert("0o") }),
+			CreateDefaultBtn("+", func() { ctr.Insert("+") }),
+			CreateDefaultBtn("-", func() { ctr.Insert("-") }),
+		),
+		container.NewHBox(
+			CreateDefaultBtn("^", func() { ctr.Insert("^") }),
+			CreateDefaultBtn("!", func() { ctr.Insert("!") }),
+			CreateDefaultBtn("=", func() { ctr.Calculate() }),
+		),
+	)
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+
+package views
+
+import (
+	"calculator/src/units"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+)
+
+// ratesFile is the file in the storage of the app holding the exchange rates
+// currencies are converted with. It is plain JSON that can be edited by hand,
+// and is replaced by ShowImportRates.
+const ratesFile = "rates.json"
+
+// LoadRates sets the exchange rates saved in the storage of a, if there are
+// any. Without them currency codes are not units.
+func LoadRates(a fyne.App) {
+	uri, err := storage.Child(a.Storage().RootURI(), ratesFile)
+	if err != nil {
+		fyne.LogError("Failed to locate exchange rates", err)
+		return
+	}
+	if exists, _ := storage.Exists(uri); !exists {
+		return
+	}
+	rates, err := readRates(uri)
+	if err != nil {
+		fyne.LogError("Failed to load exchange rates", err)
+		return
+	}
+	units.SetRates(rates)
+}
+
+// ShowImportRates opens a file dialog to choose a JSON or CSV rates file that
+// was downloaded elsewhere. Valid rates replace the current ones, are saved in
+// the storage of the app for the next runs, and are passed to onImported.
+func ShowImportRates(w fyne.Window, onImported func(*units.Rates)) {
+	open := dialog.NewFileOpen(func(file fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if file == nil {
+			return
+		}
+		file.Close()
+
+		rates, err := readRates(file.URI())
+		if err == nil {
+			err = saveRates(fyne.CurrentApp(), rates)
+		}
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		units.SetRates(rates)
+		onImported(rates)
+		dialog.ShowInformation("Exchange rates", fmt.Sprintf("Imported %d currencies with the rates of %s.", len(rates.Rates), rates.Stamp()), w)
+	}, w)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".json", ".csv"}))
+	open.Show()
+}
+
+// readRates reads the rates file at uri.
+func readRates(uri fyne.URI) (*units.Rates, error) {
+	reader, err := storage.Reader(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return units.ParseRates(data)
+}
+
+// saveRates writes rates to the rates file of a.
+func saveRates(a fyne.App, rates *units.Rates) error {
+	uri, err := storage.Child(a.Storage().RootURI(), ratesFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rates, "", "  ")
+	if err != nil {
+		return err
+	}
+	writer, err := storage.Writer(uri)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2025 Rui Barroso
+// This code is licensed under the MIT License.
+
+package views
+
+import (
+	"calculator/src/controller"
+	"calculator/src/evaluator"
+	"calculator/src/lexer"
+	"calculator/src/units"
+	"slices"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Preference keys holding the evaluator.Options.
+const (
+	backendPref   = "backend"
+	precisionPref = "precision"
+	scalePref     = "scale"
+	roundingPref  = "rounding"
+	wordSizePref  = "word_size"
+	unsignedPref  = "unsigned"
+)
+
+// Preference keys holding the evaluator.Formatter.
+const (
+	notationPref       = "notation"
+	notationDigitsPref = "notation_digits"
+	siPrefixPref       = "si_prefix"
+	groupingPref       = "grouping"
+	localePref         = "locale"
+)
+
+// LoadOptions restores the evaluator.Options and the evaluator.Formatter saved
+// in prefs into ctr.
+func LoadOptions(ctr *controller.CalculatorController, prefs fyne.Preferences) {
+	backend, err := evaluator.ParseBackend(prefs.StringWithFallback(backendPref, evaluator.Float.String()))
+	if err != nil {
+		fyne.LogError("Failed to load backend", err)
+	}
+	precision := prefs.IntWithFallback(precisionPref, evaluator.DefaultPrecision)
+	if precision <= 0 {
+		precision = evaluator.DefaultPrecision
+	}
+	scale := prefs.IntWithFallback(scalePref, evaluator.DefaultScale)
+	if scale < 0 {
+		scale = evaluator.DefaultScale
+	}
+	rounding, err := evaluator.ParseRoundingMode(prefs.StringWithFallback(roundingPref, evaluator.HalfEven.String()))
+	if err != nil {
+		fyne.LogError("Failed to load rounding mode", err)
+	}
+	wordSize := prefs.IntWithFallback(wordSizePref, evaluator.DefaultWordSize)
+	if !slices.Contains(evaluator.WordSizes, wordSize) {
+		wordSize = evaluator.DefaultWordSize
+	}
+	ctr.SetOptions(evaluator.Options{
+		Backend:   backend,
+		Precision: uint(precision),
+		Scale:     scale,
+		Rounding:  rounding,
+		WordSize:  wordSize,
+		Unsigned:  prefs.Bool(unsignedPref),
+	})
+
+	notation, err := evaluator.ParseNotation(prefs.StringWithFallback(notationPref, evaluator.General.String()))
+	if err != nil {
+		fyne.LogError("Failed to load notation", err)
+	}
+	digits := prefs.IntWithFallback(notationDigitsPref, evaluator.DefaultFormatDigits)
+	if digits < 0 {
+		digits = evaluator.DefaultFormatDigits
+	}
+	locale, err := lexer.ParseLocale(prefs.StringWithFallback(localePref, lexer.DecimalPoint.String()))
+	if err != nil {
+		fyne.LogError("Failed to load locale", err)
+	}
+	ctr.SetFormatter(evaluator.Formatter{
+		Notation: notation,
+		Digits:   digits,
+		SIPrefix: prefs.Bool(siPrefixPref),
+		Grouping: prefs.Bool(groupingPref),
+		Locale:   locale,
+	})
+}
+
+// ShowSettings opens a dialog to choose the number backend, the precision of
+// the Big backend, the scale and rounding mode of the Decimal backend, the
+// integers of the Programmer backend, the format numbers are shown in and the
+// locale they are typed and shown in.
+// Confirmed settings are applied to ctr,
+// saved in prefs and reported to onSaved. The dialog also shows the date of
+// the exchange rates, which can be imported from there.
+func ShowSettings(w fyne.Window, ctr *controller.CalculatorController, prefs fyne.Preferences, onSaved func()) {
+	opts := ctr.Options()
+
+	names := make([]string, len(evaluator.Backends))
+	for i, backend := range evaluator.Backends {
+		names[i] = backend.String()
+	}
+	backend := widget.NewSelect(names, nil)
+	backend.SetSelected(opts.Backend.String())
+
+	precision := widget.NewEntry()
+	precision.SetText(strconv.FormatUint(uint64(opts.Precision), 10))
+	precision.Validator = func(s string) error {
+		_, err := strconv.ParseUint(s, 10, 16)
+		return err
+	}
+
+	scale := widget.NewEntry()
+	scale.SetText(strconv.Itoa(opts.Scale))
+	scale.Validator = func(s string) error {
+		_, err := strconv.ParseUint(s, 10, 8)
+		return err
+	}
+
+	modes := make([]string, len(evaluator.RoundingModes))
+	for i, mode := range evaluator.RoundingModes {
+		modes[i] = mode.String()
+	}
+	rounding := widget.NewSelect(modes, nil)
+	rounding.SetSelected(opts.Rounding.String())
+
+	sizes := make([]string, len(evaluator.WordSizes))
+	for i, size := range evaluator.WordSizes {
+		sizes[i] = strconv.Itoa(size) + " bits"
+	}
+	wordSize := widget.NewSelect(sizes, nil)
+	wordSize.SetSelectedIndex(max(slices.Index(evaluator.WordSizes, opts.WordSize), 0))
+	unsigned := widget.NewCheck("Unsigned", nil)
+	unsigned.SetChecked(opts.Unsigned)
+
+	formatter := ctr.Formatter()
+	notations := make([]string, len(evaluator.Notations))
+	for i, notation := range evaluator.Notations {
+		notations[i] = notation.String()
+	}
+	notation := widget.NewSelect(notations, nil)
+	notation.SetSelected(formatter.Notation.String())
+	notationDigits := widget.NewEntry()
+	notationDigits.SetText(strconv.Itoa(formatter.Digits))
+	notationDigits.Validator = func(s string) error {
+		_, err := strconv.ParseUint(s, 10, 5)
+		return err
+	}
+	siPrefix := widget.NewCheck("SI prefixes", nil)
+	siPrefix.SetChecked(formatter.SIPrefix)
+	grouping := widget.NewCheck("Group digits", nil)
+	grouping.SetChecked(formatter.Grouping)
+	locales := make([]string, len(lexer.Locales))
+	for i, locale := range lexer.Locales {
+		locales[i] = locale.String()
+	}
+	locale := widget.NewSelect(locales, nil)
+	locale.SetSelected(formatter.Locale.String())
+
+	ratesDate := widget.NewLabel(ratesLabel(units.CurrentRates()))
+	importRates := widget.NewButton("Import", func() {
+		ShowImportRates(w, func(rates *units.Rates) { ratesDate.SetText(ratesLabel(rates)) })
+	})
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Backend", backend),
+		widget.NewFormItem("Digits", precision),
+		widget.NewFormItem("Decimal places", scale),
+		widget.NewFormItem("Rounding", rounding),
+		widget.NewFormItem("Integers", container.NewHBox(wordSize, unsigned)),
+		widget.NewFormItem("Notation", container.NewHBox(notation, notationDigits)),
+		widget.NewFormItem("Numbers", container.NewHBox(siPrefix, grouping)),
+		widget.NewFormItem("Locale", locale),
+		widget.NewFormItem("Exchange rates", container.NewHBox(ratesDate, importRates)),
+	}
+	dialog.ShowForm("Settings", "Save", "Cancel", items, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		opts.Backend, _ = evaluator.ParseBackend(backend.Selected)
+		if digits, err := strconv.ParseUint(precision.Text, 10, 16); err == nil && digits > 0 {
+			opts.Precision = uint(digits)
+		}
+		if places, err := strconv.ParseUint(scale.Text, 10, 8); err == nil {
+			opts.Scale = int(places)
+		}
+		opts.Rounding, _ = evaluator.ParseRoundingMode(rounding.Selected)
+		if i := wordSize.SelectedIndex(); i >= 0 {
+			opts.WordSize = evaluator.WordSizes[i]
+		}
+		opts.Unsigned = unsigned.Checked
+		ctr.SetOptions(opts)
+		formatter.Notation, _ = evaluator.ParseNotation(notation.Selected)
+		if digits, err := strconv.ParseUint(notationDigits.Text, 10, 5); err == nil {
+			formatter.Digits = int(digits)
+		}
+		formatter.SIPrefix = siPrefix.Checked
+		formatter.Grouping = grouping.Checked
+		formatter.Locale, _ = lexer.ParseLocale(locale.Selected)
+		ctr.SetFormatter(formatter)
+		prefs.SetString(backendPref, opts.Backend.String())
+		prefs.SetInt(precisionPref, int(opts.Precision))
+		prefs.SetInt(scalePref, opts.Scale)
+		prefs.SetString(roundingPref, opts.Rounding.String())
+		prefs.SetInt(wordSizePref, opts.WordSize)
+		prefs.SetBool(unsignedPref, opts.Unsigned)
+		prefs.SetString(notationPref, formatter.Notation.String())
+		prefs.SetInt(notationDigitsPref, formatter.Digits)
+		prefs.SetBool(siPrefixPref, formatter.SIPrefix)
+		prefs.SetBool(groupingPref, formatter.Grouping)
+		prefs.SetString(localePref, formatter.Locale.String())
+		onSaved()
+	}, w)
+}
+
+// ratesLabel describes the exchange rates of the settings dialog.
+func ratesLabel(rates *units.Rates) string {
+	if rates == nil {
+		return "None"
+	}
+	return rates.Stamp()
+}